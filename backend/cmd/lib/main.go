@@ -37,6 +37,41 @@ func UpdateProblem(jsonData *C.char) *C.char {
 	return C.CString(result)
 }
 
+// FindDuplicates scans all problems for clusters of likely duplicates
+//
+//export FindDuplicates
+func FindDuplicates() *C.char {
+	result := api.FindDuplicates()
+	return C.CString(result)
+}
+
+// MergeProblems merges the problems listed in a JSON array of IDs into the
+// kept problem, uniting their tags and notes
+//
+//export MergeProblems
+func MergeProblems(keepID C.int, dropIDs *C.char) *C.char {
+	goDropIDs := C.GoString(dropIDs)
+	result := api.MergeProblems(int(keepID), goDropIDs)
+	return C.CString(result)
+}
+
+// SetProblemStatus moves a problem to a new status (todo, attempted, solved, mastered or revisit)
+//
+//export SetProblemStatus
+func SetProblemStatus(problemID C.int, status *C.char) *C.char {
+	goStatus := C.GoString(status)
+	result := api.SetProblemStatus(int(problemID), goStatus)
+	return C.CString(result)
+}
+
+// GetStatusHistory retrieves the status transitions of a problem
+//
+//export GetStatusHistory
+func GetStatusHistory(problemID C.int) *C.char {
+	result := api.GetStatusHistory(int(problemID))
+	return C.CString(result)
+}
+
 // DeleteProblem deletes a problem by ID
 //
 //export DeleteProblem
@@ -62,6 +97,15 @@ func GetProblems(filterJSON *C.char) *C.char {
 	return C.CString(result)
 }
 
+// GetProblemsPage retrieves one page of problems with the total match count
+//
+//export GetProblemsPage
+func GetProblemsPage(filterJSON *C.char) *C.char {
+	goFilterJSON := C.GoString(filterJSON)
+	result := api.GetProblemsPage(goFilterJSON)
+	return C.CString(result)
+}
+
 // AddTag adds a new tag
 //
 //export AddTag
@@ -87,6 +131,99 @@ func DeleteTag(id C.int) *C.char {
 	return C.CString(result)
 }
 
+// RenameTag renames a tag
+//
+//export RenameTag
+func RenameTag(id C.int, newName *C.char) *C.char {
+	goNewName := C.GoString(newName)
+	result := api.RenameTag(int(id), goNewName)
+	return C.CString(result)
+}
+
+// MergeTags merges the tags listed in a JSON array of IDs into the target tag
+//
+//export MergeTags
+func MergeTags(sourceIDs *C.char, targetID C.int) *C.char {
+	goSourceIDs := C.GoString(sourceIDs)
+	result := api.MergeTags(goSourceIDs, int(targetID))
+	return C.CString(result)
+}
+
+// SetTagParent nests a tag under a parent tag (0 for top-level)
+//
+//export SetTagParent
+func SetTagParent(id C.int, parentID C.int) *C.char {
+	result := api.SetTagParent(int(id), int(parentID))
+	return C.CString(result)
+}
+
+// GetTagTree retrieves all tags arranged as a topic tree
+//
+//export GetTagTree
+func GetTagTree() *C.char {
+	result := api.GetTagTree()
+	return C.CString(result)
+}
+
+// AddAttempt records a solve attempt for a problem
+//
+//export AddAttempt
+func AddAttempt(jsonData *C.char) *C.char {
+	goJsonData := C.GoString(jsonData)
+	result := api.AddAttempt(goJsonData)
+	return C.CString(result)
+}
+
+// GetAttempts retrieves the attempt history of a problem
+//
+//export GetAttempts
+func GetAttempts(problemID C.int) *C.char {
+	result := api.GetAttempts(int(problemID))
+	return C.CString(result)
+}
+
+// DeleteAttempt deletes an attempt by ID
+//
+//export DeleteAttempt
+func DeleteAttempt(id C.int) *C.char {
+	result := api.DeleteAttempt(int(id))
+	return C.CString(result)
+}
+
+// GetDueReviews retrieves problems due for review
+//
+//export GetDueReviews
+func GetDueReviews(limit C.int) *C.char {
+	result := api.GetDueReviews(int(limit))
+	return C.CString(result)
+}
+
+// GetDueReviewsForTag retrieves problems due for review carrying the given tag
+//
+//export GetDueReviewsForTag
+func GetDueReviewsForTag(limit C.int, tag *C.char) *C.char {
+	goTag := C.GoString(tag)
+	result := api.GetDueReviewsForTag(int(limit), goTag)
+	return C.CString(result)
+}
+
+// RecordReview records a graded review (0-5) of a problem
+//
+//export RecordReview
+func RecordReview(problemID C.int, grade C.int) *C.char {
+	result := api.RecordReview(int(problemID), int(grade))
+	return C.CString(result)
+}
+
+// GetReviewForecast retrieves the number of reviews due on each of the next days
+//
+//export GetReviewForecast
+func GetReviewForecast(days C.int, tag *C.char) *C.char {
+	goTag := C.GoString(tag)
+	result := api.GetReviewForecast(int(days), goTag)
+	return C.CString(result)
+}
+
 // GetStatistics retrieves problem statistics
 //
 //export GetStatistics
@@ -95,7 +232,52 @@ func GetStatistics() *C.char {
 	return C.CString(result)
 }
 
-// ExportData exports data to file
+// GetActivityStatistics retrieves solves per day, week or month, a daily heatmap,
+// streaks and rolling counts
+//
+//export GetActivityStatistics
+func GetActivityStatistics(optionsJSON *C.char) *C.char {
+	goOptionsJSON := C.GoString(optionsJSON)
+	result := api.GetActivityStatistics(goOptionsJSON)
+	return C.CString(result)
+}
+
+// GetTimezone returns the timezone activity statistics use, empty for the system zone
+//
+//export GetTimezone
+func GetTimezone() *C.char {
+	result := api.GetTimezone()
+	return C.CString(result)
+}
+
+// SetTimezone sets the IANA timezone activity statistics use, empty for the system zone
+//
+//export SetTimezone
+func SetTimezone(name *C.char) *C.char {
+	goName := C.GoString(name)
+	result := api.SetTimezone(goName)
+	return C.CString(result)
+}
+
+// GetDifficultyScales retrieves the per-platform difficulty scales
+//
+//export GetDifficultyScales
+func GetDifficultyScales() *C.char {
+	result := api.GetDifficultyScales()
+	return C.CString(result)
+}
+
+// UpdateDifficultyScale replaces a platform's difficulty scale and rescores its problems
+//
+//export UpdateDifficultyScale
+func UpdateDifficultyScale(jsonData *C.char) *C.char {
+	goJsonData := C.GoString(jsonData)
+	result := api.UpdateDifficultyScale(goJsonData)
+	return C.CString(result)
+}
+
+// ExportData exports data to file, or to a directory for the markdown and html notebook formats.
+// The apkg and anki_text formats write an Anki deck.
 //
 //export ExportData
 func ExportData(format *C.char, filePath *C.char) *C.char {
@@ -105,7 +287,27 @@ func ExportData(format *C.char, filePath *C.char) *C.char {
 	return C.CString(result)
 }
 
-// ImportData imports data from file
+// ExportNotebook writes a Markdown or HTML solution notebook into a directory
+//
+//export ExportNotebook
+func ExportNotebook(dirPath *C.char, optionsJSON *C.char) *C.char {
+	goDirPath := C.GoString(dirPath)
+	goOptionsJSON := C.GoString(optionsJSON)
+	result := api.ExportNotebook(goDirPath, goOptionsJSON)
+	return C.CString(result)
+}
+
+// ExportAnki writes an Anki deck of the problems matching the options' filter
+//
+//export ExportAnki
+func ExportAnki(filePath *C.char, optionsJSON *C.char) *C.char {
+	goFilePath := C.GoString(filePath)
+	goOptionsJSON := C.GoString(optionsJSON)
+	result := api.ExportAnki(goFilePath, goOptionsJSON)
+	return C.CString(result)
+}
+
+// ImportData imports data from file, skipping problems that already exist
 //
 //export ImportData
 func ImportData(format *C.char, filePath *C.char) *C.char {
@@ -115,6 +317,28 @@ func ImportData(format *C.char, filePath *C.char) *C.char {
 	return C.CString(result)
 }
 
+// ImportCSV imports problems from a CSV file with a column mapping and
+// duplicate strategy, or only reports the outcome when dry_run is set
+//
+//export ImportCSV
+func ImportCSV(filePath *C.char, optionsJSON *C.char) *C.char {
+	goFilePath := C.GoString(filePath)
+	goOptionsJSON := C.GoString(optionsJSON)
+	result := api.ImportCSV(goFilePath, goOptionsJSON)
+	return C.CString(result)
+}
+
+// ImportJSON imports problems from a JSON file with a duplicate strategy, or
+// only reports the outcome when dry_run is set
+//
+//export ImportJSON
+func ImportJSON(filePath *C.char, optionsJSON *C.char) *C.char {
+	goFilePath := C.GoString(filePath)
+	goOptionsJSON := C.GoString(optionsJSON)
+	result := api.ImportJSON(goFilePath, goOptionsJSON)
+	return C.CString(result)
+}
+
 // BackupDatabase backs up the database
 //
 //export BackupDatabase
@@ -125,6 +349,47 @@ func BackupDatabase(dbPath *C.char, backupPath *C.char) *C.char {
 	return C.CString(result)
 }
 
+// GetBackupProgress reports the progress of the running or most recent backup
+//
+//export GetBackupProgress
+func GetBackupProgress() *C.char {
+	result := api.GetBackupProgress()
+	return C.CString(result)
+}
+
+// GetBackupSettings returns the automatic backup policy
+//
+//export GetBackupSettings
+func GetBackupSettings() *C.char {
+	result := api.GetBackupSettings()
+	return C.CString(result)
+}
+
+// UpdateBackupSettings replaces the automatic backup policy
+//
+//export UpdateBackupSettings
+func UpdateBackupSettings(jsonData *C.char) *C.char {
+	goJsonData := C.GoString(jsonData)
+	result := api.UpdateBackupSettings(goJsonData)
+	return C.CString(result)
+}
+
+// ListBackups lists the scheduled backups, newest first
+//
+//export ListBackups
+func ListBackups() *C.char {
+	result := api.ListBackups()
+	return C.CString(result)
+}
+
+// PruneBackups deletes the scheduled backups the retention policy does not keep
+//
+//export PruneBackups
+func PruneBackups() *C.char {
+	result := api.PruneBackups()
+	return C.CString(result)
+}
+
 // RestoreDatabase restores the database from backup
 //
 //export RestoreDatabase
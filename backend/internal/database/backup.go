@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupStepPages is the number of pages copied per backup step. Between
+// steps other connections may use the source database.
+const backupStepPages = 256
+
+// backupRetryDelay is how long to wait when a backup step made no progress
+// because the source was locked
+const backupRetryDelay = 10 * time.Millisecond
+
+// BackupProgressFunc is called after each backup step with the number of
+// pages copied so far and the total page count of the source
+type BackupProgressFunc func(done, total int)
+
+// Backup copies the open database src to destPath using the SQLite online
+// backup API, so the copy is consistent even while src is in use. The copy is
+// written to a temporary file and checked with PRAGMA integrity_check before
+// it replaces destPath, so a failed backup never clobbers an existing file.
+func Backup(src *sql.DB, destPath string, progress BackupProgressFunc) error {
+	tmpPath := destPath + ".tmp"
+	os.Remove(tmpPath)
+
+	if err := backupTo(src, tmpPath, progress); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
+
+// backupTo runs the online backup into a new database at destPath and
+// verifies the result
+func backupTo(src *sql.DB, destPath string, progress BackupProgressFunc) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer dest.Close()
+
+	ctx := context.Background()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			return copyPages(destDriver.(*sqlite3.SQLiteConn), srcDriver.(*sqlite3.SQLiteConn), progress)
+		})
+	})
+	destConn.Close()
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	if err := IntegrityCheck(dest); err != nil {
+		return fmt.Errorf("backup is corrupt: %w", err)
+	}
+	return nil
+}
+
+// copyPages steps the backup from src to dest until every page is copied
+func copyPages(dest, src *sqlite3.SQLiteConn, progress BackupProgressFunc) error {
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+
+	remaining := -1
+	for {
+		done, err := backup.Step(backupStepPages)
+		if err != nil {
+			backup.Close()
+			return err
+		}
+
+		total := backup.PageCount()
+		if progress != nil {
+			progress(total-backup.Remaining(), total)
+		}
+		if done {
+			break
+		}
+
+		// Step returns without copying anything while the source is locked
+		if backup.Remaining() == remaining {
+			time.Sleep(backupRetryDelay)
+		}
+		remaining = backup.Remaining()
+	}
+
+	return backup.Finish()
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns an error listing the
+// problems it reports
+func IntegrityCheck(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestBackup checks the backup holds the source rows, reports progress up to
+// the full page count and replaces an existing file at the destination
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(filepath.Join(dir, "source.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	for i := 0; i < 200; i++ {
+		if _, err := src.Exec(`
+			INSERT INTO problems (name, platform, difficulty, created_at, updated_at)
+			VALUES (?, 'LeetCode', 'Easy', ?, ?)
+		`, "problem", time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	destPath := filepath.Join(dir, "backup.db")
+	for run := 0; run < 2; run++ {
+		var done, total int
+		if err := Backup(src, destPath, func(d, t int) { done, total = d, t }); err != nil {
+			t.Fatal(err)
+		}
+		if total == 0 || done != total {
+			t.Errorf("run %d: progress ended at %d/%d pages", run, done, total)
+		}
+	}
+
+	dest, err := Open(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dest.Close()
+
+	var count int
+	if err := dest.QueryRow("SELECT COUNT(*) FROM problems").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 200 {
+		t.Errorf("backup has %d problems, want 200", count)
+	}
+	if err := IntegrityCheck(dest); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,489 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/platform"
+)
+
+// migration represents a single numbered schema change
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in the order it must be applied.
+// Versions must be strictly increasing; never edit or reorder an entry that
+// has already shipped, append a new one instead.
+var migrations = []migration{
+	{version: 1, name: "create base schema", up: createBaseSchema},
+	{version: 2, name: "add problems.link", up: addProblemLink},
+	{version: 3, name: "create attempts", up: createAttempts},
+	{version: 4, name: "create reviews", up: createReviews},
+	{version: 5, name: "create full-text search index", up: createSearchIndex},
+	{version: 6, name: "add tags.parent_id", up: addTagParent},
+	{version: 7, name: "create settings", up: createSettings},
+	{version: 8, name: "index problems.link", up: indexProblemLink},
+	{version: 9, name: "add problems.canonical_id", up: addCanonicalID},
+	{version: 10, name: "create difficulty scales", up: createDifficultyScales},
+	{version: 11, name: "add problem status", up: addProblemStatus},
+	{version: 12, name: "make problems.canonical_id unique", up: uniqueCanonicalID},
+	{version: 13, name: "canonicalize problems.platform", up: canonicalizePlatforms},
+}
+
+// ErrSchemaTooNew is returned when a database was written by a newer version of the library
+var ErrSchemaTooNew = errors.New("database schema is newer than this version supports")
+
+// LatestVersion returns the schema version this build of the library knows about
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the highest migration version recorded in the database
+func SchemaVersion(conn *sql.DB) (int, error) {
+	if err := ensureMigrationsTable(conn); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// migrate applies every pending migration, each in its own transaction
+func migrate(conn *sql.DB) error {
+	current, err := SchemaVersion(conn)
+	if err != nil {
+		return err
+	}
+
+	if latest := LatestVersion(); current > latest {
+		return fmt.Errorf("%w (found version %d, latest known %d)", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(conn, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration runs a migration and records it atomically
+func applyMigration(conn *sql.DB, m migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO schema_migrations (version, name, applied_at)
+		VALUES (?, ?, ?)
+	`, m.version, m.name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func ensureMigrationsTable(conn *sql.DB) error {
+	_, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// columnExists reports whether a table already has the named column
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// Migrations
+
+// createBaseSchema creates the original tables. It uses IF NOT EXISTS so that
+// databases created before schema versioning was introduced adopt version 1.
+func createBaseSchema(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS problems (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		platform TEXT NOT NULL,
+		difficulty TEXT NOT NULL,
+		solve_time INTEGER DEFAULT 0,
+		notes TEXT,
+		code_snippet TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS problem_tags (
+		problem_id INTEGER,
+		tag_id INTEGER,
+		PRIMARY KEY (problem_id, tag_id),
+		FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE,
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_problems_difficulty ON problems(difficulty);
+	CREATE INDEX IF NOT EXISTS idx_problems_platform ON problems(platform);
+	CREATE INDEX IF NOT EXISTS idx_problems_created_at ON problems(created_at);
+	CREATE INDEX IF NOT EXISTS idx_tags_name ON tags(name);
+	`)
+	return err
+}
+
+// addProblemLink adds the link column, which older databases may lack
+func addProblemLink(tx *sql.Tx) error {
+	exists, err := columnExists(tx, "problems", "link")
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE problems ADD COLUMN link TEXT DEFAULT ''")
+	return err
+}
+
+// createAttempts adds the per-problem solve attempt history
+func createAttempts(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE attempts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		problem_id INTEGER NOT NULL,
+		attempted_at DATETIME NOT NULL,
+		duration INTEGER DEFAULT 0,
+		verdict TEXT NOT NULL,
+		language TEXT DEFAULT '',
+		code TEXT DEFAULT '',
+		notes TEXT DEFAULT '',
+		FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_attempts_problem ON attempts(problem_id, attempted_at);
+	`)
+	return err
+}
+
+// createReviews adds the spaced-repetition schedule, one row per reviewed problem
+func createReviews(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE reviews (
+		problem_id INTEGER PRIMARY KEY,
+		ease_factor REAL NOT NULL DEFAULT 2.5,
+		interval_days INTEGER NOT NULL DEFAULT 0,
+		repetitions INTEGER NOT NULL DEFAULT 0,
+		lapses INTEGER NOT NULL DEFAULT 0,
+		due_at DATETIME NOT NULL,
+		last_reviewed_at DATETIME,
+		FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_reviews_due_at ON reviews(due_at);
+	`)
+	return err
+}
+
+// addTagParent lets tags form a topic tree
+func addTagParent(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE tags ADD COLUMN parent_id INTEGER REFERENCES tags(id) ON DELETE SET NULL;
+
+	CREATE INDEX idx_tags_parent_id ON tags(parent_id);
+	`)
+	return err
+}
+
+// createSettings adds a key/value store for preferences that must survive
+// restarts, such as the backup policy
+func createSettings(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`)
+	return err
+}
+
+// indexProblemLink speeds up duplicate detection, which matches problems by link
+func indexProblemLink(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_problems_link ON problems(link)`)
+	return err
+}
+
+// addCanonicalID stores the platform-specific problem ID parsed from the link
+// and fills it in for existing problems whose link matches their platform
+func addCanonicalID(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE problems ADD COLUMN canonical_id TEXT NOT NULL DEFAULT '';
+
+	CREATE INDEX idx_problems_canonical_id ON problems(platform, canonical_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, platform, COALESCE(link, '') FROM problems WHERE COALESCE(link, '') != ''")
+	if err != nil {
+		return err
+	}
+	ids := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name, link string
+		if err := rows.Scan(&id, &name, &link); err != nil {
+			rows.Close()
+			return err
+		}
+		if parsed, ok := platform.Parse(link); ok && parsed.Platform == platform.Canonical(name) {
+			ids[id] = parsed.ID
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for id, canonicalID := range ids {
+		if _, err := tx.Exec("UPDATE problems SET canonical_id = ? WHERE id = ?", canonicalID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// difficultyPoint is a seeded entry of a platform's difficulty scale
+type difficultyPoint struct {
+	value string
+	score int
+	level string
+}
+
+// defaultDifficultyScales map each platform's difficulties onto scores on a
+// Codeforces-like rating scale. The empty platform is the fallback for
+// labels a platform does not define.
+var defaultDifficultyScales = map[string][]difficultyPoint{
+	"": {
+		{"Easy", 1200, "Easy"}, {"Medium", 1600, "Medium"}, {"Hard", 2000, "Hard"},
+	},
+	platform.Codeforces: {
+		{"800", 800, "Easy"}, {"1400", 1400, "Medium"}, {"2000", 2000, "Hard"}, {"3500", 3500, "Hard"},
+	},
+	platform.AtCoder: {
+		{"100", 800, "Easy"}, {"200", 900, "Easy"}, {"300", 1100, "Easy"}, {"400", 1400, "Medium"},
+		{"500", 1700, "Medium"}, {"600", 2000, "Hard"}, {"1000", 2600, "Hard"}, {"2400", 3500, "Hard"},
+	},
+	platform.Luogu: {
+		{"1", 800, "Easy"}, {"2", 1000, "Easy"}, {"3", 1300, "Easy"}, {"4", 1600, "Medium"},
+		{"5", 1900, "Medium"}, {"6", 2300, "Hard"}, {"7", 2800, "Hard"},
+		{"入门", 800, "Easy"}, {"普及-", 1000, "Easy"}, {"普及/提高-", 1300, "Easy"}, {"普及+/提高", 1600, "Medium"},
+		{"提高+/省选-", 1900, "Medium"}, {"省选/NOI-", 2300, "Hard"}, {"NOI/NOI+/CTSC", 2800, "Hard"},
+		{"Red", 800, "Easy"}, {"Orange", 1000, "Easy"}, {"Yellow", 1300, "Easy"}, {"Green", 1600, "Medium"},
+		{"Blue", 1900, "Medium"}, {"Purple", 2300, "Hard"}, {"Black", 2800, "Hard"},
+	},
+	platform.Kattis: {
+		{"1", 800, "Easy"}, {"2.8", 1400, "Medium"}, {"5.5", 2000, "Hard"}, {"10", 3500, "Hard"},
+	},
+	platform.HackerRank: {
+		{"Advanced", 2200, "Hard"}, {"Expert", 2400, "Hard"},
+	},
+}
+
+// createDifficultyScales adds per-platform difficulty scales and stores the
+// raw platform difficulty and its normalized score on each problem. Existing
+// problems only have a level, which is scored with the fallback scale.
+func createDifficultyScales(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE difficulty_scales (
+		platform TEXT NOT NULL,
+		value TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		level TEXT NOT NULL,
+		PRIMARY KEY (platform, value)
+	);
+
+	ALTER TABLE problems ADD COLUMN raw_difficulty TEXT NOT NULL DEFAULT '';
+	ALTER TABLE problems ADD COLUMN difficulty_score INTEGER NOT NULL DEFAULT 0;
+
+	CREATE INDEX idx_problems_difficulty_score ON problems(difficulty_score);
+	`)
+	if err != nil {
+		return err
+	}
+
+	for name, points := range defaultDifficultyScales {
+		for _, p := range points {
+			if _, err := tx.Exec("INSERT INTO difficulty_scales (platform, value, score, level) VALUES (?, ?, ?, ?)",
+				name, p.value, p.score, p.level); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+	UPDATE problems SET difficulty_score = COALESCE(
+		(SELECT score FROM difficulty_scales WHERE platform = problems.platform AND value = problems.difficulty),
+		(SELECT score FROM difficulty_scales WHERE platform = '' AND value = problems.difficulty),
+		0)
+	`)
+	return err
+}
+
+// addProblemStatus adds the status workflow: each problem's current status
+// and when it was entered, and a history of every status change. Problems
+// tracked so far were all solved, so they start as solved when created.
+func addProblemStatus(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE problems ADD COLUMN status TEXT NOT NULL DEFAULT 'solved';
+	ALTER TABLE problems ADD COLUMN status_changed_at DATETIME;
+
+	CREATE INDEX idx_problems_status ON problems(status);
+
+	CREATE TABLE problem_status_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		problem_id INTEGER NOT NULL,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		changed_at DATETIME NOT NULL,
+		FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_status_history_problem ON problem_status_history(problem_id, changed_at);
+	CREATE INDEX idx_status_history_changed ON problem_status_history(to_status, changed_at);
+
+	UPDATE problems SET status_changed_at = created_at;
+
+	INSERT INTO problem_status_history (problem_id, from_status, to_status, changed_at)
+	SELECT id, '', 'solved', created_at FROM problems ORDER BY id;
+	`)
+	return err
+}
+
+// createUniqueCanonicalIDIndex allows each platform problem ID on one problem
+const createUniqueCanonicalIDIndex = `
+CREATE UNIQUE INDEX idx_problems_canonical_id_unique ON problems(platform, canonical_id)
+WHERE canonical_id != ''`
+
+// uniqueCanonicalID allows each platform problem ID on only one problem.
+// Problems saved before duplicates were rejected can share one; all but the
+// oldest lose their canonical ID, and are still found by link or name.
+func uniqueCanonicalID(tx *sql.Tx) error {
+	if err := clearDuplicateCanonicalIDs(tx); err != nil {
+		return err
+	}
+	_, err := tx.Exec(createUniqueCanonicalIDIndex)
+	return err
+}
+
+// clearDuplicateCanonicalIDs clears the canonical ID of every problem whose
+// platform problem ID is also held by an older problem
+func clearDuplicateCanonicalIDs(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	UPDATE problems SET canonical_id = ''
+	WHERE canonical_id != '' AND EXISTS (
+		SELECT 1 FROM problems older
+		WHERE older.platform = problems.platform AND older.canonical_id = problems.canonical_id
+		  AND older.id < problems.id
+	)`)
+	return err
+}
+
+// canonicalizePlatforms stores the platform of existing problems under its
+// canonical name, as new problems are, so "leetcode" and "LC" become
+// LeetCode. Problems that end up sharing a platform problem ID keep it only
+// on the oldest.
+func canonicalizePlatforms(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT DISTINCT platform FROM problems")
+	if err != nil {
+		return err
+	}
+	renames := map[string]string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		if canonical := platform.Canonical(name); canonical != name {
+			renames[name] = canonical
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil || len(renames) == 0 {
+		return err
+	}
+
+	// Renaming can join platform problem IDs, so the unique index is
+	// rebuilt once the duplicates are cleared
+	if _, err := tx.Exec("DROP INDEX idx_problems_canonical_id_unique"); err != nil {
+		return err
+	}
+	for name, canonical := range renames {
+		if _, err := tx.Exec("UPDATE problems SET platform = ? WHERE platform = ?", canonical, name); err != nil {
+			return err
+		}
+	}
+	if err := clearDuplicateCanonicalIDs(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(createUniqueCanonicalIDIndex)
+	return err
+}
@@ -0,0 +1,284 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// appliedVersions returns the migration versions recorded in db
+func appliedVersions(t *testing.T, db *sql.DB) []int {
+	t.Helper()
+
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func TestMigrationVersionsIncrease(t *testing.T) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version <= migrations[i-1].version {
+			t.Errorf("migration %d (%s) follows version %d", migrations[i].version, migrations[i].name, migrations[i-1].version)
+		}
+	}
+}
+
+func TestOpenUpgradesUnversionedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracker.db")
+
+	// The schema from before migrations were tracked: no schema_migrations
+	// table and no link column
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Exec(`
+		CREATE TABLE problems (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, platform TEXT NOT NULL,
+			difficulty TEXT NOT NULL, solve_time INTEGER DEFAULT 0, notes TEXT, code_snippet TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE problem_tags (problem_id INTEGER, tag_id INTEGER, PRIMARY KEY (problem_id, tag_id));
+		INSERT INTO problems (name, platform, difficulty, notes, code_snippet) VALUES ('Two Sum', 'lc', 'Easy', '', '');
+		INSERT INTO tags (name) VALUES ('array');
+		INSERT INTO problem_tags VALUES (1, 1);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var want []int
+	for _, m := range migrations {
+		want = append(want, m.version)
+	}
+	if got := appliedVersions(t, db); !slices.Equal(got, want) {
+		t.Errorf("applied versions = %v, want %v", got, want)
+	}
+
+	var name, platform, link, status string
+	var tags int
+	if err := db.QueryRow(`
+		SELECT name, platform, link, status, (SELECT COUNT(*) FROM problem_tags WHERE problem_id = problems.id)
+		FROM problems
+	`).Scan(&name, &platform, &link, &status, &tags); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Two Sum" || platform != "LeetCode" || link != "" || status != "solved" || tags != 1 {
+		t.Errorf("upgraded problem: %q on %q, link %q, status %q, %d tags", name, platform, link, status, tags)
+	}
+}
+
+func TestMigrateAgainChangesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracker.db")
+	db := openWithProblem(t, path, "kept")
+	db.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate an up to date database: %v", err)
+	}
+
+	if got := appliedVersions(t, db); len(got) != len(migrations) {
+		t.Errorf("applied versions = %v, want each of the %d migrations once", got, len(migrations))
+	}
+	if name := problemName(t, db); name != "kept" {
+		t.Errorf("problem = %q, want kept", name)
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracker.db")
+	db := openWithProblem(t, path, "from the future")
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, 'from a newer release')",
+		LatestVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if db, err := Open(path); !errors.Is(err, ErrSchemaTooNew) {
+		if db != nil {
+			db.Close()
+		}
+		t.Fatalf("open = %v, want ErrSchemaTooNew", err)
+	}
+
+	// The refused database is left as it was
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if version, err := SchemaVersion(raw); err != nil || version != LatestVersion()+1 {
+		t.Errorf("schema version = %d, %v; want %d", version, err, LatestVersion()+1)
+	}
+	if name := problemName(t, raw); name != "from the future" {
+		t.Errorf("problem = %q", name)
+	}
+}
+
+func TestApplyMigrationRollsBackOnFailure(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	failing := migration{version: LatestVersion() + 1, name: "fails halfway", up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec("CREATE TABLE half_done (id INTEGER)"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("SELECT * FROM no_such_table")
+		return err
+	}}
+	if err := applyMigration(db, failing); err == nil {
+		t.Fatal("failing migration succeeded")
+	}
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Error("failed migration left its table behind")
+	}
+	if version, err := SchemaVersion(db); err != nil || version != LatestVersion() {
+		t.Errorf("schema version = %d, %v; want %d", version, err, LatestVersion())
+	}
+
+	// A successful one is recorded with its version
+	passing := failing
+	passing.up = func(tx *sql.Tx) error { return nil }
+	if err := applyMigration(db, passing); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := SchemaVersion(db); err != nil || version != passing.version {
+		t.Errorf("schema version = %d, %v; want %d", version, err, passing.version)
+	}
+}
+
+func TestUniqueCanonicalIDClearsDuplicates(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Duplicates saved before the index existed
+	if _, err := db.Exec("DROP INDEX idx_problems_canonical_id_unique"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range [][2]string{{"LeetCode", "two-sum"}, {"LeetCode", "two-sum"}, {"Codeforces", "two-sum"}, {"LeetCode", ""}, {"LeetCode", ""}} {
+		if _, err := db.Exec(`
+			INSERT INTO problems (name, platform, difficulty, canonical_id, created_at, updated_at)
+			VALUES ('Two Sum', ?, 'Easy', ?, ?, ?)
+		`, p[0], p[1], time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := uniqueCanonicalID(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := tx.Query("SELECT canonical_id FROM problems ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if want := []string{"two-sum", "", "two-sum", "", ""}; !slices.Equal(ids, want) {
+		t.Errorf("canonical IDs = %q, want %q", ids, want)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO problems (name, platform, difficulty, canonical_id, created_at, updated_at)
+		VALUES ('Copy', 'LeetCode', 'Easy', 'two-sum', ?, ?)
+	`, time.Now(), time.Now()); err == nil {
+		t.Error("inserted a second LeetCode two-sum")
+	}
+}
+
+func TestCanonicalizePlatforms(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Spellings stored before platforms were canonicalized. Both LeetCode
+	// rows got the same canonical ID from their links.
+	for _, p := range [][2]string{{"leetcode", "two-sum"}, {"LC", "two-sum"}, {" cf ", ""}, {"My Judge", ""}} {
+		if _, err := db.Exec(`
+			INSERT INTO problems (name, platform, difficulty, canonical_id, created_at, updated_at)
+			VALUES ('Two Sum', ?, 'Easy', ?, ?, ?)
+		`, p[0], p[1], time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := canonicalizePlatforms(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := tx.Query("SELECT platform || '/' || canonical_id FROM problems ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, key)
+	}
+	if want := []string{"LeetCode/two-sum", "LeetCode/", "Codeforces/", "My Judge/"}; !slices.Equal(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+}
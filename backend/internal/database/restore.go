@@ -0,0 +1,153 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// sqliteHeader is the magic string at the start of every SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// ErrInvalidBackup is returned when a restore candidate is not a usable
+// database for this library
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// Restore replaces the database at dbPath, currently open as current, with
+// the backup at backupPath and returns a handle on the restored database.
+//
+// The backup is validated on a staged copy first: it must carry the SQLite
+// header, hold the problems table, migrate to the current schema and pass
+// PRAGMA integrity_check. A snapshot of the current database is written to
+// snapshotPath before the staged copy is renamed over dbPath, and restored
+// the same way if anything fails after that point.
+//
+// The returned handle is the one to keep using even on failure: current
+// itself if the restore failed before the swap, otherwise the restored
+// database or the reopened snapshot, and nil only if the rollback failed too.
+func Restore(current *sql.DB, dbPath, backupPath, snapshotPath string) (*sql.DB, error) {
+	stagedPath := dbPath + ".restore"
+	defer os.Remove(stagedPath)
+
+	if err := stageBackup(backupPath, stagedPath); err != nil {
+		return current, err
+	}
+	if err := Backup(current, snapshotPath, nil); err != nil {
+		return current, fmt.Errorf("failed to snapshot current database: %w", err)
+	}
+
+	current.Close()
+	if err := os.Rename(stagedPath, dbPath); err != nil {
+		return rollback(dbPath, snapshotPath, fmt.Errorf("failed to replace database: %w", err))
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		return rollback(dbPath, snapshotPath, err)
+	}
+	return db, nil
+}
+
+// stageBackup copies backupPath to stagedPath and validates the copy, so the
+// backup itself is never modified by migrations
+func stageBackup(backupPath, stagedPath string) error {
+	if err := checkHeader(backupPath); err != nil {
+		return err
+	}
+	if err := copyFile(backupPath, stagedPath); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", dsn(stagedPath))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'problems'
+	`).Scan(&tables); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("%w: no problems table", ErrInvalidBackup)
+	}
+	if err := IntegrityCheck(db); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	db.Close()
+
+	// Bring the staged copy up to the current schema, rejecting newer ones
+	migrated, err := Open(stagedPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer migrated.Close()
+
+	if err := IntegrityCheck(migrated); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return nil
+}
+
+// checkHeader verifies that path starts with the SQLite file header
+func checkHeader(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(file, header); err != nil || !bytes.Equal(header, sqliteHeader) {
+		return fmt.Errorf("%w: %s is not a SQLite database", ErrInvalidBackup, path)
+	}
+	return nil
+}
+
+// rollback puts the snapshot back at dbPath after a failed swap and reopens it
+func rollback(dbPath, snapshotPath string, cause error) (*sql.DB, error) {
+	stagedPath := dbPath + ".rollback"
+	defer os.Remove(stagedPath)
+
+	if err := copyFile(snapshotPath, stagedPath); err != nil {
+		return nil, fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	if err := os.Rename(stagedPath, dbPath); err != nil {
+		return nil, fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	return db, cause
+}
+
+// copyFile copies src to dst and syncs dst to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
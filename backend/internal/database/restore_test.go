@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openWithProblem opens a database at path holding a single named problem
+func openWithProblem(t *testing.T, path, name string) *sql.DB {
+	t.Helper()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO problems (name, platform, difficulty, created_at, updated_at)
+		VALUES (?, 'LeetCode', 'Easy', ?, ?)
+	`, name, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// problemName returns the name of the only problem in db
+func problemName(t *testing.T, db *sql.DB) string {
+	t.Helper()
+
+	var name string
+	if err := db.QueryRow("SELECT name FROM problems").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "tracker.db")
+	backupPath := filepath.Join(dir, "backup.db")
+	snapshotPath := filepath.Join(dir, "snapshot.db")
+
+	backup := openWithProblem(t, backupPath, "from backup")
+	backup.Close()
+	current := openWithProblem(t, dbPath, "current")
+
+	db, err := Restore(current, dbPath, backupPath, snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if got := problemName(t, db); got != "from backup" {
+		t.Errorf("restored problem = %q, want %q", got, "from backup")
+	}
+
+	snapshot, err := Open(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+	if got := problemName(t, snapshot); got != "current" {
+		t.Errorf("snapshot problem = %q, want %q", got, "current")
+	}
+}
+
+func TestRestoreMigratesLegacyBackup(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "tracker.db")
+	backupPath := filepath.Join(dir, "legacy.db")
+
+	legacy, err := sql.Open("sqlite3", backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec(`CREATE TABLE problems (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, platform TEXT NOT NULL,
+		difficulty TEXT NOT NULL, solve_time INTEGER DEFAULT 0, notes TEXT, code_snippet TEXT,
+		created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec(`INSERT INTO problems (name, platform, difficulty, created_at, updated_at)
+		VALUES ('legacy', 'Codeforces', 'Medium', ?, ?)`, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	legacy.Close()
+
+	db, err := Restore(openWithProblem(t, dbPath, "current"), dbPath, backupPath, dbPath+".snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	version, err := SchemaVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != LatestVersion() {
+		t.Errorf("schema version = %d, want %d", version, LatestVersion())
+	}
+
+	// Problems from before the status workflow were all solved
+	var status string
+	var score, changes int
+	if err := db.QueryRow(`
+		SELECT status, difficulty_score, (SELECT COUNT(*) FROM problem_status_history WHERE to_status = 'solved')
+		FROM problems
+	`).Scan(&status, &score, &changes); err != nil {
+		t.Fatal(err)
+	}
+	if status != "solved" || changes != 1 || score != 1600 {
+		t.Errorf("migrated problem: status %q, %d status changes, difficulty score %d", status, changes, score)
+	}
+}
+
+func TestRestoreRejectsInvalidBackup(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "tracker.db")
+
+	notSQLite := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notSQLite, []byte("not a database at all"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	otherApp := filepath.Join(dir, "other.db")
+	other, err := sql.Open("sqlite3", otherApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Exec("CREATE TABLE notes (body TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+
+	tooNew := filepath.Join(dir, "newer.db")
+	newer, err := Open(tooNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newer.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'future', ?)",
+		LatestVersion()+1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	newer.Close()
+
+	current := openWithProblem(t, dbPath, "current")
+	defer current.Close()
+
+	for _, backupPath := range []string{notSQLite, otherApp, tooNew} {
+		db, err := Restore(current, dbPath, backupPath, dbPath+".snapshot")
+		if !errors.Is(err, ErrInvalidBackup) {
+			t.Errorf("%s: err = %v, want ErrInvalidBackup", filepath.Base(backupPath), err)
+		}
+		if db != current {
+			t.Fatalf("%s: current handle was replaced", filepath.Base(backupPath))
+		}
+		if got := problemName(t, current); got != "current" {
+			t.Errorf("%s: current problem = %q after rejected restore", filepath.Base(backupPath), got)
+		}
+	}
+}
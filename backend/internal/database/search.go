@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// The full-text search index requires SQLite to be compiled with FTS5, which
+// go-sqlite3 only does when built with the sqlite_fts5 tag (see the Makefile).
+// Without it the index is not created and searches fall back to LIKE.
+//
+// problems_fts is keyed by problem id and kept in sync by triggers. The
+// triggers double as a marker: they only exist while the index is current.
+
+// tagNamesForProblem is the SQL expression listing the tag names of a problem
+const tagNamesForProblem = `(
+	SELECT COALESCE(group_concat(t.name, ' '), '')
+	FROM problem_tags pt
+	INNER JOIN tags t ON pt.tag_id = t.id
+	WHERE pt.problem_id = %s
+)`
+
+var searchIndexTriggers = []string{
+	"problems_fts_ai",
+	"problems_fts_au",
+	"problems_fts_ad",
+	"problem_tags_fts_ai",
+	"problem_tags_fts_ad",
+	"tags_fts_au",
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// HasSearchIndex reports whether the full-text search index is present and in sync
+func HasSearchIndex(conn *sql.DB) bool {
+	return hasSearchIndex(conn)
+}
+
+func hasSearchIndex(q rowQuerier) bool {
+	var count int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?
+	`, searchIndexTriggers[0]).Scan(&count)
+	return err == nil && count > 0
+}
+
+// createSearchIndex creates and backfills the search index when FTS5 is available
+func createSearchIndex(tx *sql.Tx) error {
+	available, err := fts5Available(tx)
+	if err != nil || !available {
+		return err
+	}
+
+	_, err = tx.Exec(`
+	CREATE VIRTUAL TABLE IF NOT EXISTS problems_fts USING fts5(
+		name, notes, code_snippet, tags,
+		tokenize = 'unicode61'
+	)`)
+	if err != nil {
+		return err
+	}
+
+	return rebuildSearchIndex(tx)
+}
+
+// ensureSearchIndex reconciles the search index with the capabilities of the
+// running build. A database last opened without FTS5 has its triggers dropped
+// so writes keep working; once FTS5 is available again the index is rebuilt.
+func ensureSearchIndex(conn *sql.DB) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	available, err := fts5Available(tx)
+	if err != nil {
+		return err
+	}
+
+	if available {
+		if hasSearchIndex(tx) {
+			return nil
+		}
+		if err := createSearchIndex(tx); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range searchIndexTriggers {
+			if _, err := tx.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rebuildSearchIndex repopulates the index from scratch and (re)creates its triggers
+func rebuildSearchIndex(tx *sql.Tx) error {
+	if _, err := tx.Exec("DELETE FROM problems_fts"); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(fmt.Sprintf(`
+		INSERT INTO problems_fts (rowid, name, notes, code_snippet, tags)
+		SELECT p.id, p.name, COALESCE(p.notes, ''), COALESCE(p.code_snippet, ''), %s
+		FROM problems p
+	`, fmt.Sprintf(tagNamesForProblem, "p.id")))
+	if err != nil {
+		return err
+	}
+
+	insertRow := fmt.Sprintf(`
+		INSERT INTO problems_fts (rowid, name, notes, code_snippet, tags)
+		VALUES (new.id, new.name, COALESCE(new.notes, ''), COALESCE(new.code_snippet, ''), %s);
+	`, fmt.Sprintf(tagNamesForProblem, "new.id"))
+
+	_, err = tx.Exec(fmt.Sprintf(`
+	DROP TRIGGER IF EXISTS problems_fts_ai;
+	CREATE TRIGGER problems_fts_ai AFTER INSERT ON problems BEGIN
+		%[1]s
+	END;
+
+	DROP TRIGGER IF EXISTS problems_fts_au;
+	CREATE TRIGGER problems_fts_au AFTER UPDATE OF name, notes, code_snippet ON problems BEGIN
+		DELETE FROM problems_fts WHERE rowid = old.id;
+		%[1]s
+	END;
+
+	DROP TRIGGER IF EXISTS problems_fts_ad;
+	CREATE TRIGGER problems_fts_ad AFTER DELETE ON problems BEGIN
+		DELETE FROM problems_fts WHERE rowid = old.id;
+	END;
+
+	DROP TRIGGER IF EXISTS problem_tags_fts_ai;
+	CREATE TRIGGER problem_tags_fts_ai AFTER INSERT ON problem_tags BEGIN
+		UPDATE problems_fts SET tags = %[2]s WHERE rowid = new.problem_id;
+	END;
+
+	DROP TRIGGER IF EXISTS problem_tags_fts_ad;
+	CREATE TRIGGER problem_tags_fts_ad AFTER DELETE ON problem_tags BEGIN
+		UPDATE problems_fts SET tags = %[3]s WHERE rowid = old.problem_id;
+	END;
+
+	DROP TRIGGER IF EXISTS tags_fts_au;
+	CREATE TRIGGER tags_fts_au AFTER UPDATE OF name ON tags BEGIN
+		UPDATE problems_fts SET tags = %[4]s
+		WHERE rowid IN (SELECT problem_id FROM problem_tags WHERE tag_id = new.id);
+	END;
+	`, insertRow,
+		fmt.Sprintf(tagNamesForProblem, "new.problem_id"),
+		fmt.Sprintf(tagNamesForProblem, "old.problem_id"),
+		fmt.Sprintf(tagNamesForProblem, "problems_fts.rowid")))
+	return err
+}
+
+// fts5Available reports whether the linked SQLite library was built with FTS5
+func fts5Available(tx *sql.Tx) (bool, error) {
+	var used int
+	if err := tx.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used); err != nil {
+		return false, err
+	}
+	return used == 1, nil
+}
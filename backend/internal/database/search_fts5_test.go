@@ -0,0 +1,26 @@
+//go:build sqlite_fts5
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFTS5Available(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if available, err := fts5Available(tx); err != nil || !available {
+		t.Fatalf("fts5Available = %v, %v; want true with the sqlite_fts5 tag", available, err)
+	}
+}
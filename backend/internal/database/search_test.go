@@ -0,0 +1,35 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchIndexFollowsFTS5(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tracker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	available, err := fts5Available(tx)
+	tx.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if HasSearchIndex(db) != available {
+		t.Errorf("search index present = %v, FTS5 available = %v", HasSearchIndex(db), available)
+	}
+	var triggers int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE '%fts%'").Scan(&triggers); err != nil {
+		t.Fatal(err)
+	}
+	if want := len(searchIndexTriggers); available && triggers != want || !available && triggers != 0 {
+		t.Errorf("%d search index triggers with FTS5 available = %v", triggers, available)
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// ArchiveFormatVersion is the archive format written by this version. Archives
+// with a newer format version are rejected on import.
+//
+// Version 2 added difficulty scales, raw difficulties and scores, and problem
+// statuses with their history. Version 1 archives still import: their
+// problems are solved and scored from their difficulty level.
+const ArchiveFormatVersion = 2
+
+// Archive is the canonical JSON export: every problem with its tags, attempts
+// and review schedule, every tag including unused ones, the settings that
+// carry over to another machine and the difficulty scales.
+// Records reference each other by name instead of database IDs and times are
+// in UTC, so exporting an imported archive reproduces it exactly.
+type Archive struct {
+	FormatVersion int               `json:"format_version"`
+	Tags          []ArchiveTag      `json:"tags"`
+	Problems      []ArchiveProblem  `json:"problems"`
+	Settings      map[string]string `json:"settings,omitempty"` // raw setting values by key
+	// DifficultyScales are absent from archives written before scales existed
+	DifficultyScales []DifficultyScale `json:"difficulty_scales,omitempty"`
+}
+
+// ArchiveTag is a tag in an archive
+type ArchiveTag struct {
+	Name      string    `json:"name"`
+	Parent    string    `json:"parent,omitempty"` // name of the parent tag
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchiveProblem is a problem in an archive
+type ArchiveProblem struct {
+	Name            string           `json:"name"`
+	Link            string           `json:"link"`
+	Platform        string           `json:"platform"`
+	Difficulty      string           `json:"difficulty"`
+	RawDifficulty   string           `json:"raw_difficulty,omitempty"`
+	DifficultyScore int              `json:"difficulty_score,omitempty"`
+	SolveTime       int              `json:"solve_time"`
+	Notes           string           `json:"notes"`
+	CodeSnippet     string           `json:"code_snippet"`
+	Status          string           `json:"status"` // absent before statuses, meaning solved
+	StatusHistory   []ArchiveStatus  `json:"status_history,omitempty"`
+	Tags            []string         `json:"tags"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	Attempts        []ArchiveAttempt `json:"attempts,omitempty"`
+	Review          *ArchiveReview   `json:"review,omitempty"`
+}
+
+// ArchiveAttempt is a solve attempt in an archive
+type ArchiveAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	Duration    int       `json:"duration"`
+	Verdict     string    `json:"verdict"`
+	Language    string    `json:"language"`
+	Code        string    `json:"code"`
+	Notes       string    `json:"notes"`
+}
+
+// ArchiveStatus is a status change of a problem in an archive
+type ArchiveStatus struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// ArchiveReview is the review schedule of a problem in an archive
+type ArchiveReview struct {
+	EaseFactor     float64   `json:"ease_factor"`
+	IntervalDays   int       `json:"interval_days"`
+	Repetitions    int       `json:"repetitions"`
+	Lapses         int       `json:"lapses"`
+	DueAt          time.Time `json:"due_at"`
+	LastReviewedAt time.Time `json:"last_reviewed_at"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorCode is a stable, machine-readable error category returned to clients
+type ErrorCode string
+
+// Error codes
+const (
+	ErrCodeNotFound       ErrorCode = "NOT_FOUND"
+	ErrCodeValidation     ErrorCode = "VALIDATION"
+	ErrCodeConflict       ErrorCode = "CONFLICT"
+	ErrCodeNotInitialized ErrorCode = "NOT_INITIALIZED"
+	ErrCodeIO             ErrorCode = "IO"
+	ErrCodeInternal       ErrorCode = "INTERNAL"
+)
+
+// FieldError describes a validation failure of a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error carrying an ErrorCode and, for validation failures, the
+// offending fields
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// NewError creates an error with the given code
+func NewError(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WrapError creates an error with the given code that wraps a cause
+func WrapError(code ErrorCode, err error, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// NewValidationError creates a VALIDATION error from field errors
+func NewValidationError(fields []FieldError) *Error {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	return &Error{
+		Code:    ErrCodeValidation,
+		Message: strings.Join(messages, "; "),
+		Fields:  fields,
+	}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause, if any
+func (e *Error) Unwrap() error {
+	return e.Err
+}
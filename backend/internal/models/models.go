@@ -88,48 +88,370 @@ func (ct CustomTime) Value() (driver.Value, error) {
 
 // Problem represents an algorithm problem record
 type Problem struct {
-	ID          int        `json:"id"`
-	Name        string     `json:"name"`
-	Link        string     `json:"link"`
-	Platform    string     `json:"platform"`
-	Difficulty  string     `json:"difficulty"`
-	SolveTime   int        `json:"solve_time"` // in minutes
-	Notes       string     `json:"notes"`
-	CodeSnippet string     `json:"code_snippet"`
-	Tags        []Tag      `json:"tags"`
-	CreatedAt   CustomTime `json:"created_at"`
-	UpdatedAt   CustomTime `json:"updated_at"`
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Link            string     `json:"link"`
+	Platform        string     `json:"platform"`
+	Difficulty      string     `json:"difficulty"`                 // Easy, Medium or Hard
+	RawDifficulty   string     `json:"raw_difficulty,omitempty"`   // platform's own difficulty, e.g. a Codeforces rating or Luogu level
+	DifficultyScore int        `json:"difficulty_score,omitempty"` // RawDifficulty on a common, Codeforces-like rating scale
+	SolveTime       int        `json:"solve_time"`                 // in minutes
+	Notes           string     `json:"notes"`
+	CodeSnippet     string     `json:"code_snippet"`
+	Status          string     `json:"status"` // todo, attempted, solved, mastered or revisit
+	StatusChangedAt CustomTime `json:"status_changed_at"`
+	CanonicalID     string     `json:"canonical_id,omitempty"` // platform's ID parsed from the link, e.g. 1520/F
+	Tags            []Tag      `json:"tags"`
+	CreatedAt       CustomTime `json:"created_at"`
+	UpdatedAt       CustomTime `json:"updated_at"`
+	// Snippet is a highlighted excerpt of the best search match, if any
+	Snippet string `json:"snippet,omitempty"`
 }
 
 // Tag represents a knowledge point tag
 type Tag struct {
 	ID        int        `json:"id"`
 	Name      string     `json:"name"`
+	ParentID  int        `json:"parent_id,omitempty"` // 0 for top-level tags
 	CreatedAt CustomTime `json:"created_at"`
 }
 
+// TagNode is a tag within the topic tree, with the number of problems
+// tagged directly and the number tagged with it or any descendant
+type TagNode struct {
+	Tag
+	ProblemCount      int       `json:"problem_count"`
+	TotalProblemCount int       `json:"total_problem_count"`
+	Children          []TagNode `json:"children"`
+}
+
+// Problem statuses. A problem moves from todo through attempted to solved
+// and mastered, and can be marked for a revisit once attempted.
+const (
+	StatusTodo      = "todo"
+	StatusAttempted = "attempted"
+	StatusSolved    = "solved"
+	StatusMastered  = "mastered"
+	StatusRevisit   = "revisit"
+)
+
+// ProblemStatuses lists the problem statuses in workflow order
+var ProblemStatuses = []string{StatusTodo, StatusAttempted, StatusSolved, StatusMastered, StatusRevisit}
+
+// StatusChange is a transition of a problem's status. FromStatus is empty
+// for the status a problem was created with.
+type StatusChange struct {
+	ProblemID  int        `json:"problem_id"`
+	FromStatus string     `json:"from_status"`
+	ToStatus   string     `json:"to_status"`
+	ChangedAt  CustomTime `json:"changed_at"`
+}
+
+// Attempt verdicts
+const (
+	VerdictAccepted          = "AC"
+	VerdictWrongAnswer       = "WA"
+	VerdictTimeLimitExceeded = "TLE"
+	VerdictGaveUp            = "GAVE_UP"
+)
+
+// Attempt represents a single solve attempt of a problem
+type Attempt struct {
+	ID          int        `json:"id"`
+	ProblemID   int        `json:"problem_id"`
+	AttemptedAt CustomTime `json:"attempted_at"`
+	Duration    int        `json:"duration"` // in minutes
+	Verdict     string     `json:"verdict"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	Notes       string     `json:"notes"`
+}
+
+// ReviewState holds the spaced-repetition schedule of a problem
+type ReviewState struct {
+	ProblemID      int        `json:"problem_id"`
+	EaseFactor     float64    `json:"ease_factor"`
+	IntervalDays   int        `json:"interval_days"`
+	Repetitions    int        `json:"repetitions"`
+	Lapses         int        `json:"lapses"`
+	DueAt          CustomTime `json:"due_at"`
+	LastReviewedAt CustomTime `json:"last_reviewed_at"`
+}
+
+// DueReview is a problem waiting to be reviewed. State is nil for problems
+// that have never been reviewed.
+type DueReview struct {
+	Problem Problem      `json:"problem"`
+	State   *ReviewState `json:"state,omitempty"`
+}
+
+// ReviewForecastDay is the number of reviews falling due on a given day
+type ReviewForecastDay struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// Tag match modes for ProblemFilter.TagMatch
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
 // ProblemFilter represents filter criteria for querying problems
 type ProblemFilter struct {
 	Difficulty  string   `json:"difficulty,omitempty"`
+	MinScore    int      `json:"min_score,omitempty"` // difficulty score range, inclusive
+	MaxScore    int      `json:"max_score,omitempty"`
 	Platform    string   `json:"platform,omitempty"`
+	Status      string   `json:"status,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+	TagMatch    string   `json:"tag_match,omitempty"` // any (default) or all
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
 	StartDate   string   `json:"start_date,omitempty"`
 	EndDate     string   `json:"end_date,omitempty"`
 	SearchQuery string   `json:"search_query,omitempty"`
+
+	// Paging and ordering. Cursor takes precedence over Offset.
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	SortBy    string `json:"sort_by,omitempty"`    // created_at, updated_at, name, difficulty, solve_time, platform, relevance
+	SortOrder string `json:"sort_order,omitempty"` // asc or desc
+}
+
+// ProblemPage is one page of a problem query
+type ProblemPage struct {
+	Problems   []Problem `json:"problems"`
+	Total      int       `json:"total"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
 
 // Statistics represents problem statistics
 type Statistics struct {
-	TotalProblems    int            `json:"total_problems"`
-	ByDifficulty     map[string]int `json:"by_difficulty"`
-	ByPlatform       map[string]int `json:"by_platform"`
-	ByTag            map[string]int `json:"by_tag"`
-	AverageSolveTime float64        `json:"average_solve_time"`
+	TotalProblems       int                 `json:"total_problems"`
+	ByDifficulty        map[string]int      `json:"by_difficulty"`
+	ByScoreRange        []ScoreBucket       `json:"by_score_range"` // problems per difficulty score range
+	ByPlatform          map[string]int      `json:"by_platform"`
+	ByStatus            map[string]int      `json:"by_status"`
+	ByTag               map[string]int      `json:"by_tag"`
+	ByTagRollup         map[string]int      `json:"by_tag_rollup"` // includes problems under descendant tags
+	AverageSolveTime    float64             `json:"average_solve_time"`
+	FirstTrySuccessRate float64             `json:"first_try_success_rate"` // share of attempted problems accepted on the first attempt
+	ResolveTrend        []ResolveTrendPoint `json:"resolve_trend"`
+}
+
+// ScoreBucket counts the problems whose difficulty score is in [Min, Max)
+type ScoreBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// Difficulty levels every difficulty scale maps onto
+const (
+	DifficultyEasy   = "Easy"
+	DifficultyMedium = "Medium"
+	DifficultyHard   = "Hard"
+)
+
+// DifficultyPoint is one entry of a difficulty scale. Value is a label such
+// as "Medium" or "普及-", or a number on numeric scales such as Codeforces
+// ratings; numbers between two points are interpolated.
+type DifficultyPoint struct {
+	Value string `json:"value"`
+	Score int    `json:"score"` // on a Codeforces-like rating scale
+	Level string `json:"level"` // Easy, Medium or Hard
+}
+
+// DifficultyScale maps a platform's difficulties to scores and levels
+type DifficultyScale struct {
+	Platform string            `json:"platform"` // empty for the fallback used by every platform
+	Points   []DifficultyPoint `json:"points"`
+}
+
+// ResolveTrendPoint is the average solve duration of the Nth accepted attempt across problems
+type ResolveTrendPoint struct {
+	SolveNumber     int     `json:"solve_number"`
+	Problems        int     `json:"problems"`
+	AverageDuration float64 `json:"average_duration"`
+}
+
+// Activity series granularities for ActivityOptions.Granularity
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week" // weeks start on Monday
+	GranularityMonth = "month"
+)
+
+// ActivityOptions selects the solve activity time series
+type ActivityOptions struct {
+	Granularity string `json:"granularity,omitempty"` // day (default), week or month
+	Periods     int    `json:"periods,omitempty"`     // most recent periods to include; 0 for all since the first solve
+	Timezone    string `json:"timezone,omitempty"`    // IANA name such as Europe/Berlin; defaults to the timezone setting
+}
+
+// ActivityPoint is the number of problems solved in the period starting on Date
+type ActivityPoint struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// ActivityStatistics is solve activity over time. A problem counts as solved
+// on each day it moved to solved, in the statistics' timezone.
+type ActivityStatistics struct {
+	Timezone      string          `json:"timezone"`
+	Granularity   string          `json:"granularity"`
+	Series        []ActivityPoint `json:"series"`         // per period, oldest first
+	Heatmap       []ActivityPoint `json:"heatmap"`        // per day for the last 365 days, ending today
+	CurrentStreak int             `json:"current_streak"` // consecutive days with a solve, ending today or yesterday
+	LongestStreak int             `json:"longest_streak"`
+	Last7Days     int             `json:"last_7_days"` // solves today and in the 6 days before
+	Last30Days    int             `json:"last_30_days"`
+	TotalSolved   int             `json:"total_solved"`
+}
+
+// BackupProgress reports how far the running or most recent backup got
+type BackupProgress struct {
+	Running    bool    `json:"running"`
+	Path       string  `json:"path,omitempty"`
+	PagesDone  int     `json:"pages_done"`
+	PagesTotal int     `json:"pages_total"`
+	Percent    float64 `json:"percent"`
+}
+
+// BackupSettings is the policy for automatic backups. Backups are taken when
+// the database is opened and at most once every IntervalHours; pruning keeps
+// the newest backup of each of the last KeepDaily days, KeepWeekly weeks and
+// KeepMonthly months.
+type BackupSettings struct {
+	Enabled       bool   `json:"enabled"`
+	Directory     string `json:"directory"` // defaults to "backups" next to the database
+	IntervalHours int    `json:"interval_hours"`
+	KeepDaily     int    `json:"keep_daily"`
+	KeepWeekly    int    `json:"keep_weekly"`
+	KeepMonthly   int    `json:"keep_monthly"`
+}
+
+// BackupInfo describes a backup file in the backup directory
+type BackupInfo struct {
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	Size      int64      `json:"size"`
+	CreatedAt CustomTime `json:"created_at"`
+}
+
+// RestoreResult describes a completed restore
+type RestoreResult struct {
+	SnapshotPath string `json:"snapshot_path"` // copy of the data replaced by the restore
+}
+
+// Import strategies for problems that already exist, matched by link or by
+// name and platform when the imported problem has no link
+const (
+	ImportSkip      = "skip"       // keep the existing problem
+	ImportOverwrite = "overwrite"  // replace the existing problem with the imported one
+	ImportMergeTags = "merge_tags" // add the imported tags to the existing problem
+	ImportKeepBoth  = "keep_both"  // import as a new problem anyway
+)
+
+// ImportOptions controls an import. Mapping applies to CSV only and maps
+// problem fields (name, link, platform, difficulty, solve_time, tags, notes,
+// code_snippet, created_at, updated_at) to column headers; fields left out
+// use the headers written by the CSV export.
+type ImportOptions struct {
+	Strategy string            `json:"strategy,omitempty"` // defaults to ImportSkip
+	Mapping  map[string]string `json:"mapping,omitempty"`
+	DryRun   bool              `json:"dry_run"` // run the import and roll it back
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"` // rows read
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Skipped int              `json:"skipped"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+// ImportRowError describes why a row could not be imported
+type ImportRowError struct {
+	Row     int          `json:"row"` // CSV line (the header is line 1) or position in a JSON array
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// Notebook export formats and groupings
+const (
+	NotebookMarkdown = "markdown"
+	NotebookHTML     = "html"
+
+	NotebookGroupByTag      = "tag"
+	NotebookGroupByPlatform = "platform"
+)
+
+// NotebookOptions controls a solution notebook export. Templates in
+// TemplateDir replace the built-in ones with the same file name.
+type NotebookOptions struct {
+	Format      string         `json:"format"`             // markdown (default) or html
+	GroupBy     string         `json:"group_by,omitempty"` // tag (default) or platform
+	Title       string         `json:"title,omitempty"`
+	TemplateDir string         `json:"template_dir,omitempty"`
+	Filter      *ProblemFilter `json:"filter,omitempty"`
+}
+
+// Anki export formats
+const (
+	AnkiPackage = "apkg"      // Anki package with a ready-made deck
+	AnkiText    = "anki_text" // tab-separated text for Anki's File > Import
+)
+
+// AnkiOptions controls an Anki export
+type AnkiOptions struct {
+	Format   string         `json:"format"`              // apkg (default) or anki_text
+	DeckName string         `json:"deck_name,omitempty"` // defaults to "Algorithm Problems"
+	Filter   *ProblemFilter `json:"filter,omitempty"`
+}
+
+// Reasons two problems are considered duplicates
+const (
+	DuplicateLink        = "link"         // same link or platform problem ID
+	DuplicateSimilarName = "similar_name" // same platform and a similar name
+)
+
+// DuplicateMatch is an existing problem that another problem may duplicate
+type DuplicateMatch struct {
+	Problem    Problem `json:"problem"`
+	Reason     string  `json:"reason"`
+	Similarity float64 `json:"similarity"` // name similarity from 0 to 1
+}
+
+// DuplicateCluster is a group of problems that are likely the same problem
+type DuplicateCluster struct {
+	Reason   string    `json:"reason"` // link if any pair shares a link, else similar_name
+	Problems []Problem `json:"problems"`
+}
+
+// Warning codes of successful responses
+const (
+	WarningSimilarProblem = "SIMILAR_PROBLEM"
+)
+
+// Warning is a non-fatal notice attached to a successful response
+type Warning struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // Response represents a generic API response
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success  bool         `json:"success"`
+	Message  string       `json:"message,omitempty"`
+	Code     ErrorCode    `json:"code,omitempty"`   // set on failure
+	Fields   []FieldError `json:"fields,omitempty"` // set on validation failure
+	Data     interface{}  `json:"data,omitempty"`
+	Warnings []Warning    `json:"warnings,omitempty"` // set on success, e.g. a possible duplicate
 }
@@ -0,0 +1,335 @@
+package platform
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Canonical platform names
+const (
+	LeetCode   = "LeetCode"
+	LeetCodeCN = "LeetCode CN"
+	Codeforces = "Codeforces"
+	AtCoder    = "AtCoder"
+	Luogu      = "Luogu"
+	HackerRank = "HackerRank"
+	Kattis     = "Kattis"
+	SPOJ       = "SPOJ"
+	CSES       = "CSES"
+)
+
+// Problem is a problem link resolved against the registry
+type Problem struct {
+	Platform string // canonical platform name
+	ID       string // platform-specific problem ID, e.g. 1520/F or abc300_d
+	Link     string // normalized link
+}
+
+// platform describes a judge: the names it goes by, the hosts serving its
+// problems and how to read a problem ID from a link path
+type platform struct {
+	name    string
+	aliases []string
+	hosts   []string
+	// parse returns the problem ID and normalized link for the path segments
+	// of a link on host
+	parse func(host string, segments []string) (id, link string, ok bool)
+}
+
+var registry = []platform{
+	{
+		name:    LeetCode,
+		aliases: []string{"lc"},
+		hosts:   []string{"leetcode.com"},
+		parse:   parseLeetCode("https://leetcode.com"),
+	},
+	{
+		name:    LeetCodeCN,
+		aliases: []string{"lccn", "力扣"},
+		hosts:   []string{"leetcode.cn", "leetcode-cn.com"},
+		parse:   parseLeetCode("https://leetcode.cn"),
+	},
+	{
+		name:    Codeforces,
+		aliases: []string{"cf"},
+		hosts:   []string{"codeforces.com", "codeforces.ru", "m1.codeforces.com", "m2.codeforces.com", "m3.codeforces.com"},
+		parse:   parseCodeforces,
+	},
+	{
+		name:    AtCoder,
+		aliases: []string{"atc"},
+		hosts:   []string{"atcoder.jp", "*.contest.atcoder.jp"},
+		parse:   parseAtCoder,
+	},
+	{
+		name:    Luogu,
+		aliases: []string{"洛谷"},
+		hosts:   []string{"luogu.com.cn", "luogu.com", "luogu.org"},
+		parse:   parseLuogu,
+	},
+	{
+		name:    HackerRank,
+		aliases: []string{"hr"},
+		hosts:   []string{"hackerrank.com"},
+		parse:   parseHackerRank,
+	},
+	{
+		name:    Kattis,
+		aliases: []string{"open kattis"},
+		hosts:   []string{"*.kattis.com", "kattis.com"},
+		parse:   parseKattis,
+	},
+	{
+		name:  SPOJ,
+		hosts: []string{"spoj.com"},
+		parse: parseSPOJ,
+	},
+	{
+		name:  CSES,
+		hosts: []string{"cses.fi"},
+		parse: parseCSES,
+	},
+}
+
+// Names returns the canonical names of the known platforms
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, p := range registry {
+		names[i] = p.name
+	}
+	return names
+}
+
+// Canonical returns the canonical name of a known platform given any of its
+// spellings ("leetcode", "LC", "LeetCode"), or name with surrounding space
+// trimmed if the platform is unknown
+func Canonical(name string) string {
+	key := nameKey(name)
+	for _, p := range registry {
+		if nameKey(p.name) == key {
+			return p.name
+		}
+		for _, alias := range p.aliases {
+			if nameKey(alias) == key {
+				return p.name
+			}
+		}
+	}
+	return strings.TrimSpace(name)
+}
+
+// nameKey folds case, spaces and punctuation so that "LeetCode CN",
+// "leetcode-cn" and "LeetCodeCN" compare equal
+func nameKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch r {
+		case ' ', '\t', '-', '_', '.':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Parse resolves a problem link to its platform, problem ID and normalized
+// link. Links without a scheme are accepted; ok is false for links of
+// unknown platforms or pages that are not a single problem.
+func Parse(link string) (Problem, bool) {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return Problem{}, false
+	}
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return Problem{}, false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	var segments []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	for _, p := range registry {
+		if !p.serves(host) {
+			continue
+		}
+		id, normalized, ok := p.parse(host, segments)
+		if !ok {
+			return Problem{}, false
+		}
+		return Problem{Platform: p.name, ID: id, Link: normalized}, true
+	}
+	return Problem{}, false
+}
+
+// serves reports whether host belongs to the platform. A "*." host pattern
+// matches any subdomain.
+func (p platform) serves(host string) bool {
+	for _, h := range p.hosts {
+		if suffix := strings.TrimPrefix(h, "*"); suffix != h {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	slugPattern          = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	codeforcesContest    = regexp.MustCompile(`^[0-9]+$`)
+	codeforcesIndex      = regexp.MustCompile(`^[A-Za-z][0-9]?$`)
+	atcoderName          = regexp.MustCompile(`^[a-z0-9_-]+$`)
+	atcoderLegacyContest = regexp.MustCompile(`^([a-z0-9_-]+)\.contest\.atcoder\.jp$`)
+	luoguProblem         = regexp.MustCompile(`^[A-Za-z_]+[0-9][0-9A-Za-z_]*$`)
+	kattisProblem        = regexp.MustCompile(`^[a-z0-9.]+$`)
+	spojProblem          = regexp.MustCompile(`^[A-Z0-9_]+$`)
+	csesTask             = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// parseLeetCode reads /problems/<slug>, optionally under /contest/<name>,
+// and links to the problem on base
+func parseLeetCode(base string) func(string, []string) (string, string, bool) {
+	return func(_ string, segments []string) (string, string, bool) {
+		if len(segments) >= 3 && segments[0] == "contest" {
+			segments = segments[2:]
+		}
+		if len(segments) < 2 || segments[0] != "problems" {
+			return "", "", false
+		}
+		slug := strings.ToLower(segments[1])
+		if !slugPattern.MatchString(slug) {
+			return "", "", false
+		}
+		return slug, base + "/problems/" + slug + "/", true
+	}
+}
+
+// parseCodeforces reads problemset, contest and gym problem links. Contest
+// and problemset links share an ID; gym problems are prefixed with "gym/".
+func parseCodeforces(_ string, segments []string) (string, string, bool) {
+	var contest, index string
+	gym := false
+	switch {
+	case len(segments) >= 4 && segments[0] == "problemset" && segments[1] == "problem":
+		contest, index = segments[2], segments[3]
+	case len(segments) >= 4 && segments[0] == "contest" && segments[2] == "problem":
+		contest, index = segments[1], segments[3]
+	case len(segments) >= 4 && segments[0] == "gym" && segments[2] == "problem":
+		contest, index, gym = segments[1], segments[3], true
+	default:
+		return "", "", false
+	}
+	if !codeforcesContest.MatchString(contest) || !codeforcesIndex.MatchString(index) {
+		return "", "", false
+	}
+
+	index = strings.ToUpper(index)
+	if gym {
+		return "gym/" + contest + "/" + index, "https://codeforces.com/gym/" + contest + "/problem/" + index, true
+	}
+	return contest + "/" + index, "https://codeforces.com/problemset/problem/" + contest + "/" + index, true
+}
+
+// parseAtCoder reads /contests/<contest>/tasks/<task>, and /tasks/<task> on
+// the legacy <contest>.contest.atcoder.jp hosts
+func parseAtCoder(host string, segments []string) (string, string, bool) {
+	var contest, task string
+	if m := atcoderLegacyContest.FindStringSubmatch(host); m != nil {
+		if len(segments) < 2 || segments[0] != "tasks" {
+			return "", "", false
+		}
+		contest, task = m[1], segments[1]
+	} else {
+		if len(segments) < 4 || segments[0] != "contests" || segments[2] != "tasks" {
+			return "", "", false
+		}
+		contest, task = segments[1], segments[3]
+	}
+
+	contest, task = strings.ToLower(contest), strings.ToLower(task)
+	if !atcoderName.MatchString(contest) || !atcoderName.MatchString(task) {
+		return "", "", false
+	}
+	return task, "https://atcoder.jp/contests/" + contest + "/tasks/" + task, true
+}
+
+// parseLuogu reads /problem/<id>, such as P1001 or CF1520F
+func parseLuogu(_ string, segments []string) (string, string, bool) {
+	if len(segments) < 2 || segments[0] != "problem" || !luoguProblem.MatchString(segments[1]) {
+		return "", "", false
+	}
+	id := segments[1]
+	return id, "https://www.luogu.com.cn/problem/" + id, true
+}
+
+// parseHackerRank reads /challenges/<slug>, optionally under
+// /contests/<name>
+func parseHackerRank(_ string, segments []string) (string, string, bool) {
+	if len(segments) >= 3 && segments[0] == "contests" {
+		segments = segments[2:]
+	}
+	if len(segments) < 2 || segments[0] != "challenges" {
+		return "", "", false
+	}
+	slug := strings.ToLower(segments[1])
+	if !slugPattern.MatchString(slug) {
+		return "", "", false
+	}
+	return slug, "https://www.hackerrank.com/challenges/" + slug + "/problem", true
+}
+
+// parseKattis reads /problems/<id> on any Kattis instance, optionally under
+// /contests/<name>
+func parseKattis(_ string, segments []string) (string, string, bool) {
+	if len(segments) >= 3 && segments[0] == "contests" {
+		segments = segments[2:]
+	}
+	if len(segments) < 2 || segments[0] != "problems" {
+		return "", "", false
+	}
+	id := strings.ToLower(segments[1])
+	if !kattisProblem.MatchString(id) {
+		return "", "", false
+	}
+	return id, "https://open.kattis.com/problems/" + id, true
+}
+
+// parseSPOJ reads /problems/<CODE>
+func parseSPOJ(_ string, segments []string) (string, string, bool) {
+	if len(segments) < 2 || segments[0] != "problems" {
+		return "", "", false
+	}
+	code := strings.ToUpper(segments[1])
+	if !spojProblem.MatchString(code) {
+		return "", "", false
+	}
+	return code, "https://www.spoj.com/problems/" + code + "/", true
+}
+
+// parseCSES reads /problemset/task/<id> and the other per-task problemset
+// pages
+func parseCSES(_ string, segments []string) (string, string, bool) {
+	if len(segments) < 3 || segments[0] != "problemset" {
+		return "", "", false
+	}
+	switch segments[1] {
+	case "task", "view", "stats", "hack":
+	default:
+		return "", "", false
+	}
+	if !csesTask.MatchString(segments[2]) {
+		return "", "", false
+	}
+	return segments[2], "https://cses.fi/problemset/task/" + segments[2], true
+}
@@ -0,0 +1,79 @@
+package platform
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		link string
+		want Problem
+	}{
+		{"https://leetcode.com/problems/two-sum/description/",
+			Problem{LeetCode, "two-sum", "https://leetcode.com/problems/two-sum/"}},
+		{"leetcode.com/contest/weekly-contest-300/problems/Decode-The-Message",
+			Problem{LeetCode, "decode-the-message", "https://leetcode.com/problems/decode-the-message/"}},
+		{"https://leetcode-cn.com/problems/two-sum",
+			Problem{LeetCodeCN, "two-sum", "https://leetcode.cn/problems/two-sum/"}},
+		{"http://www.codeforces.com/contest/1520/problem/f",
+			Problem{Codeforces, "1520/F", "https://codeforces.com/problemset/problem/1520/F"}},
+		{"https://codeforces.com/problemset/problem/1520/F?locale=ru",
+			Problem{Codeforces, "1520/F", "https://codeforces.com/problemset/problem/1520/F"}},
+		{"https://m1.codeforces.com/gym/102001/problem/A",
+			Problem{Codeforces, "gym/102001/A", "https://codeforces.com/gym/102001/problem/A"}},
+		{"https://atcoder.jp/contests/abc300/tasks/abc300_d?lang=en",
+			Problem{AtCoder, "abc300_d", "https://atcoder.jp/contests/abc300/tasks/abc300_d"}},
+		{"http://arc100.contest.atcoder.jp/tasks/arc100_a",
+			Problem{AtCoder, "arc100_a", "https://atcoder.jp/contests/arc100/tasks/arc100_a"}},
+		{"https://www.luogu.org/problem/P1001",
+			Problem{Luogu, "P1001", "https://www.luogu.com.cn/problem/P1001"}},
+		{"https://www.hackerrank.com/contests/projecteuler/challenges/euler001",
+			Problem{HackerRank, "euler001", "https://www.hackerrank.com/challenges/euler001/problem"}},
+		{"https://nus.kattis.com/problems/Hello",
+			Problem{Kattis, "hello", "https://open.kattis.com/problems/hello"}},
+		{"https://www.spoj.com/problems/prime1",
+			Problem{SPOJ, "PRIME1", "https://www.spoj.com/problems/PRIME1/"}},
+		{"https://cses.fi/problemset/stats/1671/",
+			Problem{CSES, "1671", "https://cses.fi/problemset/task/1671"}},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.link)
+		if !ok || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", tt.link, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, link := range []string{
+		"",
+		"https://example.com/problems/two-sum",
+		"https://leetcode.com/problemset/all/",
+		"https://codeforces.com/contest/1520",
+		"https://codeforces.com/problemset/problem/abc/F",
+		"https://atcoder.jp/contests/abc300",
+		"https://cses.fi/problemset/",
+		"ftp://cses.fi/problemset/task/1671",
+		"https://notcodeforces.com/contest/1520/problem/F",
+	} {
+		if got, ok := Parse(link); ok {
+			t.Errorf("Parse(%q) = %+v, want no match", link, got)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		"leetcode":      LeetCode,
+		" LC ":          LeetCode,
+		"leetcode-cn":   LeetCodeCN,
+		"LeetCodeCN":    LeetCodeCN,
+		"CF":            Codeforces,
+		"atcoder":       AtCoder,
+		"spoj":          SPOJ,
+		"Project Euler": "Project Euler",
+	}
+	for name, want := range tests {
+		if got := Canonical(name); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// ExportArchive reads the whole database into an archive. Problems are in
+// insertion order, tags by name and attempts by time, so the result only
+// depends on the data.
+func (r *Repository) ExportArchive() (*models.Archive, error) {
+	archive := &models.Archive{
+		FormatVersion: models.ArchiveFormatVersion,
+		Tags:          []models.ArchiveTag{},
+		Problems:      []models.ArchiveProblem{},
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := exportArchiveTags(tx, archive); err != nil {
+		return nil, err
+	}
+	index, err := exportArchiveProblems(tx, archive)
+	if err != nil {
+		return nil, err
+	}
+	if err := exportArchiveAttempts(tx, archive, index); err != nil {
+		return nil, err
+	}
+	if err := exportArchiveReviews(tx, archive, index); err != nil {
+		return nil, err
+	}
+	if err := exportArchiveStatusHistory(tx, archive, index); err != nil {
+		return nil, err
+	}
+	if err := exportArchiveSettings(tx, archive); err != nil {
+		return nil, err
+	}
+	if err := exportArchiveDifficultyScales(tx, archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func exportArchiveTags(tx *sql.Tx, archive *models.Archive) error {
+	rows, err := tx.Query(`
+		SELECT t.name, COALESCE(parent.name, ''), t.created_at
+		FROM tags t
+		LEFT JOIN tags parent ON parent.id = t.parent_id
+		ORDER BY t.name
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag models.ArchiveTag
+		var createdAt models.CustomTime
+		if err := rows.Scan(&tag.Name, &tag.Parent, &createdAt); err != nil {
+			return err
+		}
+		tag.CreatedAt = createdAt.UTC()
+		archive.Tags = append(archive.Tags, tag)
+	}
+	return rows.Err()
+}
+
+// exportArchiveProblems adds every problem with its tags and returns the
+// position of each problem ID in archive.Problems
+func exportArchiveProblems(tx *sql.Tx, archive *models.Archive) (map[int]int, error) {
+	rows, err := tx.Query(`
+		SELECT id, name, COALESCE(link, ''), platform, difficulty, raw_difficulty, difficulty_score,
+		       COALESCE(solve_time, 0), COALESCE(notes, ''), COALESCE(code_snippet, ''), status, created_at, updated_at
+		FROM problems
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := make(map[int]int)
+	for rows.Next() {
+		var id int
+		var p models.ArchiveProblem
+		var createdAt, updatedAt models.CustomTime
+		if err := rows.Scan(&id, &p.Name, &p.Link, &p.Platform, &p.Difficulty, &p.RawDifficulty, &p.DifficultyScore,
+			&p.SolveTime, &p.Notes, &p.CodeSnippet, &p.Status, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		p.CreatedAt = createdAt.UTC()
+		p.UpdatedAt = updatedAt.UTC()
+		p.Tags = []string{}
+		index[id] = len(archive.Problems)
+		archive.Problems = append(archive.Problems, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tagRows, err := tx.Query(`
+		SELECT pt.problem_id, t.name
+		FROM problem_tags pt
+		INNER JOIN tags t ON t.id = pt.tag_id
+		ORDER BY t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var problemID int
+		var name string
+		if err := tagRows.Scan(&problemID, &name); err != nil {
+			return nil, err
+		}
+		p := &archive.Problems[index[problemID]]
+		p.Tags = append(p.Tags, name)
+	}
+	return index, tagRows.Err()
+}
+
+func exportArchiveAttempts(tx *sql.Tx, archive *models.Archive, index map[int]int) error {
+	rows, err := tx.Query(`
+		SELECT problem_id, attempted_at, duration, verdict, language, code, notes
+		FROM attempts
+		ORDER BY attempted_at, id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var problemID int
+		var a models.ArchiveAttempt
+		var attemptedAt models.CustomTime
+		if err := rows.Scan(&problemID, &attemptedAt, &a.Duration, &a.Verdict, &a.Language, &a.Code, &a.Notes); err != nil {
+			return err
+		}
+		a.AttemptedAt = attemptedAt.UTC()
+		p := &archive.Problems[index[problemID]]
+		p.Attempts = append(p.Attempts, a)
+	}
+	return rows.Err()
+}
+
+func exportArchiveStatusHistory(tx *sql.Tx, archive *models.Archive, index map[int]int) error {
+	rows, err := tx.Query(`
+		SELECT problem_id, from_status, to_status, changed_at
+		FROM problem_status_history
+		ORDER BY changed_at, id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var problemID int
+		var c models.ArchiveStatus
+		var changedAt models.CustomTime
+		if err := rows.Scan(&problemID, &c.FromStatus, &c.ToStatus, &changedAt); err != nil {
+			return err
+		}
+		c.ChangedAt = changedAt.UTC()
+		p := &archive.Problems[index[problemID]]
+		p.StatusHistory = append(p.StatusHistory, c)
+	}
+	return rows.Err()
+}
+
+func exportArchiveReviews(tx *sql.Tx, archive *models.Archive, index map[int]int) error {
+	rows, err := tx.Query(`
+		SELECT problem_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at
+		FROM reviews
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var problemID int
+		var review models.ArchiveReview
+		var dueAt, lastReviewedAt models.CustomTime
+		if err := rows.Scan(&problemID, &review.EaseFactor, &review.IntervalDays, &review.Repetitions,
+			&review.Lapses, &dueAt, &lastReviewedAt); err != nil {
+			return err
+		}
+		review.DueAt = dueAt.UTC()
+		review.LastReviewedAt = lastReviewedAt.UTC()
+		archive.Problems[index[problemID]].Review = &review
+	}
+	return rows.Err()
+}
+
+func exportArchiveSettings(tx *sql.Tx, archive *models.Archive) error {
+	rows, err := tx.Query("SELECT key, value FROM settings")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		if archive.Settings == nil {
+			archive.Settings = make(map[string]string)
+		}
+		archive.Settings[key] = value
+	}
+	return rows.Err()
+}
+
+func exportArchiveDifficultyScales(tx *sql.Tx, archive *models.Archive) error {
+	rows, err := tx.Query("SELECT platform, value, score, level FROM difficulty_scales ORDER BY platform, score, value")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var p models.DifficultyPoint
+		if err := rows.Scan(&name, &p.Value, &p.Score, &p.Level); err != nil {
+			return err
+		}
+		if n := len(archive.DifficultyScales); n == 0 || archive.DifficultyScales[n-1].Platform != name {
+			archive.DifficultyScales = append(archive.DifficultyScales, models.DifficultyScale{Platform: name})
+		}
+		scale := &archive.DifficultyScales[len(archive.DifficultyScales)-1]
+		scale.Points = append(scale.Points, p)
+	}
+	return rows.Err()
+}
+
+// ImportArchive imports an archive in one transaction. Tags are created by
+// name, keeping existing tags and their parents, and settings and difficulty
+// scale points are only written when missing unless strategy is overwrite. Problems are matched
+// against existing ones as in ImportProblems; the attempts and review of a
+// problem are imported when the problem is created, and replace the existing
+// ones when it is overwritten.
+func (r *Repository) ImportArchive(archive *models.Archive, strategy string, dryRun bool) ([]ImportOutcome, []error, error) {
+	prepare := func(tx *sql.Tx) error {
+		if err := r.importArchiveTags(tx, archive.Tags); err != nil {
+			return err
+		}
+		if err := importArchiveSettings(tx, archive.Settings, strategy == models.ImportOverwrite); err != nil {
+			return err
+		}
+		return importArchiveDifficultyScales(tx, archive.DifficultyScales, strategy == models.ImportOverwrite)
+	}
+
+	return r.runImport(len(archive.Problems), dryRun, prepare, func(tx *sql.Tx, i int) (ImportOutcome, error) {
+		return r.importArchiveProblem(tx, &archive.Problems[i], strategy)
+	})
+}
+
+// importArchiveTags creates missing tags, then links each tag without a
+// parent to its archived parent unless that would form a cycle
+func (r *Repository) importArchiveTags(tx *sql.Tx, tags []models.ArchiveTag) error {
+	ids := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		id, err := getOrCreateTagAt(tx, tag.Name, tag.CreatedAt)
+		if err != nil {
+			return err
+		}
+		ids[tag.Name] = id
+	}
+
+	parents, err := loadTagParents(tx)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if tag.Parent == "" {
+			continue
+		}
+		id := ids[tag.Name]
+		parentID, ok := ids[tag.Parent]
+		if !ok {
+			if parentID, err = getOrCreateTagAt(tx, tag.Parent, tag.CreatedAt); err != nil {
+				return err
+			}
+			ids[tag.Parent] = parentID
+		}
+		if parents[id] != 0 || isTagAncestor(parents, id, parentID) {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE tags SET parent_id = ? WHERE id = ?", parentID, id); err != nil {
+			return err
+		}
+		parents[id] = parentID
+	}
+	return nil
+}
+
+// getOrCreateTagAt returns the ID of the named tag, creating it with the given
+// creation time if needed
+func getOrCreateTagAt(tx *sql.Tx, name string, createdAt time.Time) (int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM tags WHERE name = ?", name).Scan(&id)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	result, err := tx.Exec("INSERT INTO tags (name, created_at) VALUES (?, ?)", name, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	newID, err := result.LastInsertId()
+	return int(newID), err
+}
+
+// importArchiveSettings writes settings that are not set yet, or all of them
+// when overwrite is set
+func importArchiveSettings(tx *sql.Tx, settings map[string]string, overwrite bool) error {
+	query := `
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO NOTHING
+	`
+	if overwrite {
+		query = `
+			INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+		`
+	}
+
+	now := time.Now()
+	for key, value := range settings {
+		if _, err := tx.Exec(query, key, value, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importArchiveDifficultyScales writes difficulty scale points that are not
+// defined yet, or all of them when overwrite is set
+func importArchiveDifficultyScales(tx *sql.Tx, scales []models.DifficultyScale, overwrite bool) error {
+	query := `
+		INSERT INTO difficulty_scales (platform, value, score, level) VALUES (?, ?, ?, ?)
+		ON CONFLICT(platform, value) DO NOTHING
+	`
+	if overwrite {
+		query = `
+			INSERT INTO difficulty_scales (platform, value, score, level) VALUES (?, ?, ?, ?)
+			ON CONFLICT(platform, value) DO UPDATE SET score = excluded.score, level = excluded.level
+		`
+	}
+
+	for _, scale := range scales {
+		for _, p := range scale.Points {
+			if _, err := tx.Exec(query, scale.Platform, p.Value, p.Score, p.Level); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importArchiveProblem imports one archived problem with its attempts and review
+func (r *Repository) importArchiveProblem(tx *sql.Tx, archived *models.ArchiveProblem, strategy string) (ImportOutcome, error) {
+	problem := &models.Problem{
+		Name:            archived.Name,
+		Link:            archived.Link,
+		Platform:        archived.Platform,
+		Difficulty:      archived.Difficulty,
+		RawDifficulty:   archived.RawDifficulty,
+		DifficultyScore: archived.DifficultyScore,
+		SolveTime:       archived.SolveTime,
+		Notes:           archived.Notes,
+		CodeSnippet:     archived.CodeSnippet,
+		Status:          archived.Status,
+		CreatedAt:       models.CustomTime{Time: archived.CreatedAt},
+		UpdatedAt:       models.CustomTime{Time: archived.UpdatedAt},
+	}
+	for _, name := range archived.Tags {
+		problem.Tags = append(problem.Tags, models.Tag{Name: name})
+	}
+	if n := len(archived.StatusHistory); n > 0 {
+		problem.StatusChangedAt = models.CustomTime{Time: archived.StatusHistory[n-1].ChangedAt}
+	}
+
+	outcome, err := r.importProblem(tx, problem, strategy)
+	if err != nil {
+		return outcome, err
+	}
+
+	switch {
+	case outcome == ImportCreated:
+	case outcome == ImportUpdated && strategy == models.ImportOverwrite:
+		if _, err := tx.Exec("DELETE FROM attempts WHERE problem_id = ?", problem.ID); err != nil {
+			return outcome, err
+		}
+		if _, err := tx.Exec("DELETE FROM reviews WHERE problem_id = ?", problem.ID); err != nil {
+			return outcome, err
+		}
+	default:
+		return outcome, nil
+	}
+
+	if len(archived.StatusHistory) > 0 {
+		if err := replaceStatusHistory(tx, problem.ID, archived.StatusHistory); err != nil {
+			return outcome, err
+		}
+	}
+	for _, a := range archived.Attempts {
+		if _, err := tx.Exec(`
+			INSERT INTO attempts (problem_id, attempted_at, duration, verdict, language, code, notes)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, problem.ID, a.AttemptedAt, a.Duration, a.Verdict, a.Language, a.Code, a.Notes); err != nil {
+			return outcome, err
+		}
+	}
+	if review := archived.Review; review != nil {
+		if _, err := tx.Exec(`
+			INSERT INTO reviews (problem_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, problem.ID, review.EaseFactor, review.IntervalDays, review.Repetitions, review.Lapses,
+			review.DueAt.UTC(), review.LastReviewedAt.UTC()); err != nil {
+			return outcome, err
+		}
+	}
+	return outcome, nil
+}
+
+// replaceStatusHistory replaces a problem's status history with an archived
+// one, whose last transition is when the current status was entered
+func replaceStatusHistory(tx *sql.Tx, problemID int, history []models.ArchiveStatus) error {
+	if _, err := tx.Exec("DELETE FROM problem_status_history WHERE problem_id = ?", problemID); err != nil {
+		return err
+	}
+	for _, c := range history {
+		if err := recordStatusChange(tx, problemID, c.FromStatus, c.ToStatus, c.ChangedAt.UTC()); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("UPDATE problems SET status_changed_at = ? WHERE id = ?",
+		history[len(history)-1].ChangedAt.UTC(), problemID)
+	return err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// CreateAttempt records a new solve attempt. In the same transaction the
+// problem moves to the status nextStatus returns for its current one, if
+// nextStatus is not nil.
+func (r *Repository) CreateAttempt(attempt *models.Attempt, nextStatus func(status string) string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM problems WHERE id = ?", attempt.ProblemID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return models.NewError(models.ErrCodeNotFound, "problem %d not found", attempt.ProblemID)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO attempts (problem_id, attempted_at, duration, verdict, language, code, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, attempt.ProblemID, attempt.AttemptedAt.Time, attempt.Duration, attempt.Verdict,
+		attempt.Language, attempt.Code, attempt.Notes)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if nextStatus != nil {
+		if err := setProblemStatus(tx, attempt.ProblemID, status, nextStatus(status), attempt.AttemptedAt.Time); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	attempt.ID = int(id)
+
+	return nil
+}
+
+// GetAttempts retrieves all attempts of a problem, oldest first
+func (r *Repository) GetAttempts(problemID int) ([]models.Attempt, error) {
+	rows, err := r.db.Query(`
+		SELECT id, problem_id, attempted_at, duration, verdict, language, code, notes
+		FROM attempts
+		WHERE problem_id = ?
+		ORDER BY attempted_at, id
+	`, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.Attempt{}
+	for rows.Next() {
+		var a models.Attempt
+		err := rows.Scan(&a.ID, &a.ProblemID, &a.AttemptedAt, &a.Duration, &a.Verdict,
+			&a.Language, &a.Code, &a.Notes)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// DeleteAttempt deletes an attempt by ID
+func (r *Repository) DeleteAttempt(id int) error {
+	result, err := r.db.Exec("DELETE FROM attempts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return models.NewError(models.ErrCodeNotFound, "attempt %d not found", id)
+	}
+	return nil
+}
+
+// loadAttemptStatistics fills in the attempt-based fields of stats
+func (r *Repository) loadAttemptStatistics(stats *models.Statistics) error {
+	// First-try success rate over every problem that has at least one attempt
+	err := r.db.QueryRow(`
+		SELECT COALESCE(AVG(CASE WHEN verdict = ? THEN 1.0 ELSE 0.0 END), 0.0)
+		FROM (
+			SELECT verdict,
+			       ROW_NUMBER() OVER (PARTITION BY problem_id ORDER BY attempted_at, id) AS n
+			FROM attempts
+		)
+		WHERE n = 1
+	`, models.VerdictAccepted).Scan(&stats.FirstTrySuccessRate)
+	if err != nil {
+		return err
+	}
+
+	// Average duration of the 1st, 2nd, ... accepted attempt of each problem
+	rows, err := r.db.Query(`
+		SELECT n, COUNT(*), AVG(duration)
+		FROM (
+			SELECT duration,
+			       ROW_NUMBER() OVER (PARTITION BY problem_id ORDER BY attempted_at, id) AS n
+			FROM attempts
+			WHERE verdict = ?
+		)
+		GROUP BY n
+		ORDER BY n
+	`, models.VerdictAccepted)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats.ResolveTrend = []models.ResolveTrendPoint{}
+	for rows.Next() {
+		var point models.ResolveTrendPoint
+		if err := rows.Scan(&point.SolveNumber, &point.Problems, &point.AverageDuration); err != nil {
+			return err
+		}
+		stats.ResolveTrend = append(stats.ResolveTrend, point)
+	}
+
+	return rows.Err()
+}
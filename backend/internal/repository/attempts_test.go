@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestGetAttemptsWithoutAttempts(t *testing.T) {
+	repo := newTestRepository(t)
+	problem := createTestProblem(t, repo, "untouched")
+
+	attempts, err := repo.GetAttempts(problem.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts == nil || len(attempts) != 0 {
+		t.Errorf("attempts = %#v, want an empty slice", attempts)
+	}
+}
+
+func TestAttemptStatistics(t *testing.T) {
+	repo := newTestRepository(t)
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	type attempt struct {
+		hours    int
+		verdict  string
+		duration int
+	}
+	ac, wa := models.VerdictAccepted, models.VerdictWrongAnswer
+	problems := []struct {
+		name     string
+		attempts []attempt // in insertion order
+	}{
+		{"first try", []attempt{{0, ac, 30}, {48, ac, 10}}},
+		{"second try", []attempt{{0, wa, 45}, {1, ac, 20}, {72, ac, 6}}},
+		// Ordered by time, not insertion: the wrong answer came first
+		{"recorded late", []attempt{{5, ac, 40}, {2, wa, 15}}},
+		// Attempts at the same time are ordered by ID
+		{"same time", []attempt{{3, ac, 50}, {3, wa, 5}}},
+		{"never attempted", nil},
+	}
+	for _, p := range problems {
+		problem := createTestProblem(t, repo, p.name)
+		for _, a := range p.attempts {
+			err := repo.CreateAttempt(&models.Attempt{ProblemID: problem.ID, Verdict: a.verdict, Duration: a.duration,
+				AttemptedAt: models.CustomTime{Time: start.Add(time.Duration(a.hours) * time.Hour)}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stats, err := repo.GetStatistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two of the four attempted problems were accepted on the first attempt
+	if stats.FirstTrySuccessRate != 0.5 {
+		t.Errorf("first try success rate = %v, want 0.5", stats.FirstTrySuccessRate)
+	}
+	want := []models.ResolveTrendPoint{
+		{SolveNumber: 1, Problems: 4, AverageDuration: 35},
+		{SolveNumber: 2, Problems: 2, AverageDuration: 8},
+	}
+	if !reflect.DeepEqual(stats.ResolveTrend, want) {
+		t.Errorf("resolve trend = %+v, want %+v", stats.ResolveTrend, want)
+	}
+}
+
+func TestAttemptStatisticsWithoutAttempts(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "untouched")
+
+	stats, err := repo.GetStatistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FirstTrySuccessRate != 0 || stats.ResolveTrend == nil || len(stats.ResolveTrend) != 0 {
+		t.Errorf("first try rate %v, resolve trend %#v; want 0 and an empty trend", stats.FirstTrySuccessRate, stats.ResolveTrend)
+	}
+}
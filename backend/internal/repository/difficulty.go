@@ -0,0 +1,125 @@
+package repository
+
+import "github.com/algorithmtracker/backend/internal/models"
+
+// GetDifficultyScale retrieves the points of a platform's difficulty scale,
+// ordered by score. An unknown platform has an empty scale.
+func (r *Repository) GetDifficultyScale(platform string) ([]models.DifficultyPoint, error) {
+	rows, err := r.db.Query(`
+		SELECT value, score, level FROM difficulty_scales
+		WHERE platform = ? ORDER BY score, value
+	`, platform)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []models.DifficultyPoint
+	for rows.Next() {
+		var p models.DifficultyPoint
+		if err := rows.Scan(&p.Value, &p.Score, &p.Level); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// GetDifficultyScales retrieves every difficulty scale, ordered by platform
+// with the fallback scale first
+func (r *Repository) GetDifficultyScales() ([]models.DifficultyScale, error) {
+	rows, err := r.db.Query(`
+		SELECT platform, value, score, level FROM difficulty_scales
+		ORDER BY platform, score, value
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scales := []models.DifficultyScale{}
+	for rows.Next() {
+		var name string
+		var p models.DifficultyPoint
+		if err := rows.Scan(&name, &p.Value, &p.Score, &p.Level); err != nil {
+			return nil, err
+		}
+		if n := len(scales); n == 0 || scales[n-1].Platform != name {
+			scales = append(scales, models.DifficultyScale{Platform: name})
+		}
+		scales[len(scales)-1].Points = append(scales[len(scales)-1].Points, p)
+	}
+	return scales, rows.Err()
+}
+
+// SaveDifficultyScale replaces a platform's difficulty scale
+func (r *Repository) SaveDifficultyScale(scale *models.DifficultyScale) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM difficulty_scales WHERE platform = ?", scale.Platform); err != nil {
+		return err
+	}
+	for _, p := range scale.Points {
+		if _, err := tx.Exec("INSERT INTO difficulty_scales (platform, value, score, level) VALUES (?, ?, ?, ?)",
+			scale.Platform, p.Value, p.Score, p.Level); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetProblemDifficulties retrieves the ID, platform and difficulty fields of
+// every problem on platform, or of all problems if platform is empty
+func (r *Repository) GetProblemDifficulties(platform string) ([]models.Problem, error) {
+	query := "SELECT id, platform, difficulty, raw_difficulty, difficulty_score FROM problems"
+	var args []interface{}
+	if platform != "" {
+		query += " WHERE platform = ?"
+		args = append(args, platform)
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []models.Problem
+	for rows.Next() {
+		var p models.Problem
+		if err := rows.Scan(&p.ID, &p.Platform, &p.Difficulty, &p.RawDifficulty, &p.DifficultyScore); err != nil {
+			return nil, err
+		}
+		problems = append(problems, p)
+	}
+	return problems, rows.Err()
+}
+
+// SetDifficultyScores stores the difficulty score of problems in one
+// transaction. It is a rescoring rather than an edit, so the other fields and
+// the update time are left untouched.
+func (r *Repository) SetDifficultyScores(problems []models.Problem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE problems SET difficulty_score = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range problems {
+		if _, err := stmt.Exec(p.DifficultyScore, p.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
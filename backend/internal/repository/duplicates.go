@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// ProblemKey holds the fields of a problem that identify it, for duplicate
+// detection
+type ProblemKey struct {
+	ID          int
+	Name        string
+	Platform    string
+	Link        string
+	CanonicalID string
+}
+
+// FindLinkDuplicate returns the ID of another problem with the same link, or
+// with the same platform and canonical ID, or 0 if there is none. problem.ID
+// itself is never reported, so an update does not match its own row.
+func (r *Repository) FindLinkDuplicate(problem *models.Problem) (int, error) {
+	key := *problem
+	setCanonicalID(&key)
+	if key.Link == "" && key.CanonicalID == "" {
+		return 0, nil
+	}
+
+	var id int
+	err := r.db.QueryRow(`
+		SELECT id FROM problems
+		WHERE id != ? AND ((? != '' AND link = ?) OR (? != '' AND platform = ? AND canonical_id = ?))
+		ORDER BY id LIMIT 1
+	`, key.ID, key.Link, key.Link, key.CanonicalID, key.Platform, key.CanonicalID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// canonicalIDConflict converts a violation of the unique platform problem ID
+// index by problem into a CONFLICT error. Other errors are returned as is.
+func canonicalIDConflict(err error, problem *models.Problem) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique ||
+		!strings.Contains(sqliteErr.Error(), "canonical_id") {
+		return err
+	}
+	message := fmt.Sprintf("%s problem %s already exists", problem.Platform, problem.CanonicalID)
+	return &models.Error{
+		Code:    models.ErrCodeConflict,
+		Message: message,
+		Fields:  []models.FieldError{{Field: "link", Message: message}},
+	}
+}
+
+// GetProblemKeys retrieves the identifying fields of every problem on
+// platform, or of all problems if platform is empty, ordered by ID
+func (r *Repository) GetProblemKeys(platform string) ([]ProblemKey, error) {
+	query := "SELECT id, name, platform, COALESCE(link, ''), canonical_id FROM problems"
+	var args []interface{}
+	if platform != "" {
+		query += " WHERE platform = ?"
+		args = append(args, platform)
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ProblemKey
+	for rows.Next() {
+		var k ProblemKey
+		if err := rows.Scan(&k.ID, &k.Name, &k.Platform, &k.Link, &k.CanonicalID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// MergeProblems folds the problems dropIDs into keepID and deletes them.
+// The kept problem gains their tags, attempts and any notes it does not
+// already contain; an empty link, code snippet or solve time is filled in
+// from the first dropped problem that has one, and it keeps the earliest
+// creation time. A dropped problem's review schedule is only kept when
+// keepID has none, taking the most recently reviewed one.
+func (r *Repository) MergeProblems(keepID int, dropIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var kept models.Problem
+	var notes, code sql.NullString
+	err = tx.QueryRow(`
+		SELECT platform, COALESCE(link, ''), solve_time, notes, code_snippet, created_at
+		FROM problems WHERE id = ?
+	`, keepID).Scan(&kept.Platform, &kept.Link, &kept.SolveTime, &notes, &code, &kept.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.NewError(models.ErrCodeNotFound, "problem %d not found", keepID)
+	}
+	if err != nil {
+		return err
+	}
+	kept.Notes, kept.CodeSnippet = notes.String, code.String
+
+	var placeholders []string
+	var args []interface{}
+	seen := map[int]bool{keepID: true}
+	for _, id := range dropIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	if len(args) == 0 {
+		return tx.Commit()
+	}
+	in := strings.Join(placeholders, ",")
+
+	// Load the dropped problems, which are folded in the order given
+	dropped := make(map[int]models.Problem, len(args))
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT id, COALESCE(link, ''), solve_time, COALESCE(notes, ''), COALESCE(code_snippet, ''), created_at
+		FROM problems WHERE id IN (%s)
+	`, in), args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var p models.Problem
+		if err := rows.Scan(&p.ID, &p.Link, &p.SolveTime, &p.Notes, &p.CodeSnippet, &p.CreatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		dropped[p.ID] = p
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if len(dropped) != len(args) {
+		return models.NewError(models.ErrCodeNotFound, "one or more problems to merge do not exist")
+	}
+
+	for _, id := range args {
+		p := dropped[id.(int)]
+		if note := strings.TrimSpace(p.Notes); note != "" && !strings.Contains(kept.Notes, note) {
+			if strings.TrimSpace(kept.Notes) == "" {
+				kept.Notes = note
+			} else {
+				kept.Notes = strings.TrimRight(kept.Notes, "\n") + "\n\n" + note
+			}
+		}
+		if kept.Link == "" {
+			kept.Link = p.Link
+		}
+		if kept.CodeSnippet == "" {
+			kept.CodeSnippet = p.CodeSnippet
+		}
+		if kept.SolveTime == 0 {
+			kept.SolveTime = p.SolveTime
+		}
+		if p.CreatedAt.Before(kept.CreatedAt.Time) {
+			kept.CreatedAt = p.CreatedAt
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT OR IGNORE INTO problem_tags (problem_id, tag_id)
+		SELECT ?, tag_id FROM problem_tags WHERE problem_id IN (%s)
+	`, in), append([]interface{}{keepID}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE attempts SET problem_id = ? WHERE problem_id IN (%s)", in),
+		append([]interface{}{keepID}, args...)...); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT OR IGNORE INTO reviews (problem_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at)
+		SELECT ?, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at
+		FROM reviews WHERE problem_id IN (%s)
+		ORDER BY last_reviewed_at DESC LIMIT 1
+	`, in), append([]interface{}{keepID}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM problems WHERE id IN (%s)", in), args...); err != nil {
+		return err
+	}
+
+	setCanonicalID(&kept)
+	_, err = tx.Exec(`
+		UPDATE problems
+		SET link = ?, solve_time = ?, notes = ?, code_snippet = ?, canonical_id = ?, created_at = ?, updated_at = ?
+		WHERE id = ?
+	`, kept.Link, kept.SolveTime, kept.Notes, kept.CodeSnippet, kept.CanonicalID, kept.CreatedAt.Time, time.Now(), keepID)
+	if err != nil {
+		return canonicalIDConflict(err, &kept)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// ImportOutcome is what an import did with one problem
+type ImportOutcome string
+
+const (
+	ImportCreated ImportOutcome = "created"
+	ImportUpdated ImportOutcome = "updated"
+	ImportSkipped ImportOutcome = "skipped"
+)
+
+// ImportProblems writes problems in a single transaction, resolving problems
+// that already exist with strategy. Each problem is applied in a savepoint,
+// so one that fails is reported in errs without undoing the others. With
+// dryRun the transaction is rolled back once every problem has been applied,
+// which still reports what the import would do.
+//
+// Imported created_at and updated_at values are kept; zero values default to
+// the current time. On success problem IDs are set to the created or updated
+// row.
+func (r *Repository) ImportProblems(problems []*models.Problem, strategy string, dryRun bool) ([]ImportOutcome, []error, error) {
+	return r.runImport(len(problems), dryRun, nil, func(tx *sql.Tx, i int) (ImportOutcome, error) {
+		return r.importProblem(tx, problems[i], strategy)
+	})
+}
+
+// runImport calls prepare and then apply for each of count items in one
+// transaction, applying each item in its own savepoint. It commits unless
+// dryRun is set.
+func (r *Repository) runImport(count int, dryRun bool, prepare func(tx *sql.Tx) error,
+	apply func(tx *sql.Tx, i int) (ImportOutcome, error)) ([]ImportOutcome, []error, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if prepare != nil {
+		if err := prepare(tx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	outcomes := make([]ImportOutcome, count)
+	errs := make([]error, count)
+	for i := 0; i < count; i++ {
+		if _, err := tx.Exec("SAVEPOINT import_item"); err != nil {
+			return nil, nil, err
+		}
+
+		outcomes[i], errs[i] = apply(tx, i)
+		if errs[i] != nil {
+			if _, err := tx.Exec("ROLLBACK TO import_item"); err != nil {
+				return nil, nil, err
+			}
+		}
+		if _, err := tx.Exec("RELEASE import_item"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if dryRun {
+		return outcomes, errs, nil
+	}
+	return outcomes, errs, tx.Commit()
+}
+
+// importProblem applies one imported problem within tx
+func (r *Repository) importProblem(tx *sql.Tx, problem *models.Problem, strategy string) (ImportOutcome, error) {
+	now := time.Now()
+	if problem.CreatedAt.IsZero() {
+		problem.CreatedAt = models.CustomTime{Time: now}
+	}
+	if problem.UpdatedAt.IsZero() {
+		problem.UpdatedAt = problem.CreatedAt
+	}
+	if problem.Status == "" {
+		problem.Status = models.StatusSolved
+	}
+
+	existingID := 0
+	if strategy != models.ImportKeepBoth {
+		var err error
+		if existingID, err = findImportDuplicate(tx, problem); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case existingID == 0:
+		return ImportCreated, r.insertImportedProblem(tx, problem, strategy == models.ImportKeepBoth)
+	case strategy == models.ImportOverwrite:
+		problem.ID = existingID
+		return ImportUpdated, r.overwriteProblem(tx, problem)
+	case strategy == models.ImportMergeTags:
+		problem.ID = existingID
+		added, err := r.addProblemTags(tx, existingID, problem.Tags)
+		if err != nil || added == 0 {
+			return ImportSkipped, err
+		}
+		_, err = tx.Exec("UPDATE problems SET updated_at = ? WHERE id = ?", now, existingID)
+		return ImportUpdated, err
+	default:
+		problem.ID = existingID
+		return ImportSkipped, nil
+	}
+}
+
+// findImportDuplicate returns the ID of the oldest problem with the same link
+// or platform problem ID, or with the same name and platform when problem has
+// no link, or 0
+func findImportDuplicate(tx *sql.Tx, problem *models.Problem) (int, error) {
+	var id int
+	var err error
+	if link := strings.TrimSpace(problem.Link); link != "" {
+		key := *problem
+		setCanonicalID(&key)
+		err = tx.QueryRow(`
+			SELECT id FROM problems
+			WHERE link = ? OR (? != '' AND platform = ? AND canonical_id = ?)
+			ORDER BY id LIMIT 1
+		`, link, key.CanonicalID, key.Platform, key.CanonicalID).Scan(&id)
+	} else {
+		err = tx.QueryRow(`
+			SELECT id FROM problems
+			WHERE platform = ? AND name = ? COLLATE NOCASE
+			ORDER BY id LIMIT 1
+		`, problem.Platform, strings.TrimSpace(problem.Name)).Scan(&id)
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// insertImportedProblem inserts problem with its own timestamps. Its status
+// counts as entered when it was created unless a status time is given. A
+// copy kept alongside an existing problem with the same platform problem ID
+// is stored without the ID, which must be unique.
+func (r *Repository) insertImportedProblem(tx *sql.Tx, problem *models.Problem, keepBoth bool) error {
+	setCanonicalID(problem)
+	if keepBoth && problem.CanonicalID != "" {
+		var taken int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM problems WHERE platform = ? AND canonical_id = ?",
+			problem.Platform, problem.CanonicalID).Scan(&taken); err != nil {
+			return err
+		}
+		if taken > 0 {
+			problem.CanonicalID = ""
+		}
+	}
+	if problem.StatusChangedAt.IsZero() {
+		problem.StatusChangedAt = problem.CreatedAt
+	}
+	result, err := tx.Exec(`
+		INSERT INTO problems (name, link, platform, difficulty, raw_difficulty, difficulty_score, solve_time, notes, code_snippet,
+		                      status, status_changed_at, canonical_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.RawDifficulty, problem.DifficultyScore,
+		problem.SolveTime, problem.Notes, problem.CodeSnippet, problem.Status, problem.StatusChangedAt.Time,
+		problem.CanonicalID, problem.CreatedAt.Time, problem.UpdatedAt.Time)
+	if err != nil {
+		return canonicalIDConflict(err, problem)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	problem.ID = int(id)
+
+	if err := recordStatusChange(tx, problem.ID, "", problem.Status, problem.StatusChangedAt.Time); err != nil {
+		return err
+	}
+
+	_, err = r.addProblemTags(tx, problem.ID, problem.Tags)
+	return err
+}
+
+// overwriteProblem replaces every field and the tags of problem.ID. A
+// changed status is recorded as a transition made now.
+func (r *Repository) overwriteProblem(tx *sql.Tx, problem *models.Problem) error {
+	var status string
+	if err := tx.QueryRow("SELECT status, status_changed_at FROM problems WHERE id = ?", problem.ID).
+		Scan(&status, &problem.StatusChangedAt); err != nil {
+		return err
+	}
+	if problem.Status != status {
+		problem.StatusChangedAt = models.CustomTime{Time: time.Now()}
+		if err := recordStatusChange(tx, problem.ID, status, problem.Status, problem.StatusChangedAt.Time); err != nil {
+			return err
+		}
+	}
+
+	setCanonicalID(problem)
+	_, err := tx.Exec(`
+		UPDATE problems
+		SET name = ?, link = ?, platform = ?, difficulty = ?, raw_difficulty = ?, difficulty_score = ?, solve_time = ?,
+		    notes = ?, code_snippet = ?, status = ?, status_changed_at = ?, canonical_id = ?, created_at = ?, updated_at = ?
+		WHERE id = ?
+	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.RawDifficulty, problem.DifficultyScore,
+		problem.SolveTime, problem.Notes, problem.CodeSnippet, problem.Status, problem.StatusChangedAt.Time,
+		problem.CanonicalID, problem.CreatedAt.Time, problem.UpdatedAt.Time, problem.ID)
+	if err != nil {
+		return canonicalIDConflict(err, problem)
+	}
+
+	if _, err := tx.Exec("DELETE FROM problem_tags WHERE problem_id = ?", problem.ID); err != nil {
+		return err
+	}
+	_, err = r.addProblemTags(tx, problem.ID, problem.Tags)
+	return err
+}
+
+// addProblemTags tags a problem, creating missing tags, and returns how many
+// tags the problem did not have yet
+func (r *Repository) addProblemTags(tx *sql.Tx, problemID int, tags []models.Tag) (int, error) {
+	added := 0
+	for _, tag := range tags {
+		tagID, err := r.getOrCreateTag(tx, tag.Name)
+		if err != nil {
+			return 0, err
+		}
+
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO problem_tags (problem_id, tag_id)
+			VALUES (?, ?)
+		`, problemID, tagID)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// Page size limits for GetProblemsPage
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Sort fields accepted in ProblemFilter.SortBy
+const (
+	SortByCreatedAt  = "created_at"
+	SortByUpdatedAt  = "updated_at"
+	SortByName       = "name"
+	SortByDifficulty = "difficulty"
+	SortBySolveTime  = "solve_time"
+	SortByPlatform   = "platform"
+	SortByRelevance  = "relevance"
+)
+
+// sortField describes how to order problems by one field
+type sortField struct {
+	expr string // ORDER BY and keyset comparison expression
+	key  string // value selected for the keyset cursor
+	// zeroLast sorts rows whose value is 0, meaning unset, after all others
+	// in either direction
+	zeroLast bool
+}
+
+// unsetLastKey replaces an unset sort value so that it sorts after every set
+// one in ascending order
+const unsetLastKey = math.MaxInt32
+
+var sortFields = map[string]sortField{
+	SortByCreatedAt:  {expr: "p.created_at", key: "CAST(p.created_at AS TEXT)"},
+	SortByUpdatedAt:  {expr: "p.updated_at", key: "CAST(p.updated_at AS TEXT)"},
+	SortByName:       {expr: "p.name COLLATE NOCASE", key: "p.name"},
+	SortByDifficulty: {expr: "p.difficulty_score", key: "p.difficulty_score", zeroLast: true},
+	SortBySolveTime:  {expr: "p.solve_time", key: "p.solve_time"},
+	SortByPlatform:   {expr: "p.platform COLLATE NOCASE", key: "p.platform"},
+}
+
+// problemSort is the resolved ordering of a problem query
+type problemSort struct {
+	name       string
+	descending bool
+	field      sortField
+}
+
+// resolveSort determines the ordering requested by a filter. Searches default
+// to relevance order, everything else to newest first.
+func resolveSort(filter *models.ProblemFilter, searching bool) (problemSort, error) {
+	sortBy, order := "", ""
+	if filter != nil {
+		sortBy, order = filter.SortBy, strings.ToLower(filter.SortOrder)
+	}
+
+	if sortBy == "" {
+		if searching {
+			sortBy = SortByRelevance
+		} else {
+			sortBy = SortByCreatedAt
+			if order == "" {
+				order = "desc"
+			}
+		}
+	}
+
+	if order != "" && order != "asc" && order != "desc" {
+		return problemSort{}, models.NewError(models.ErrCodeValidation, "invalid sort order %q, use 'asc' or 'desc'", order)
+	}
+
+	if sortBy == SortByRelevance {
+		if !searching {
+			return resolveSort(&models.ProblemFilter{SortOrder: order}, false)
+		}
+		return problemSort{name: SortByRelevance}, nil
+	}
+
+	field, ok := sortFields[sortBy]
+	if !ok {
+		return problemSort{}, models.NewError(models.ErrCodeValidation, "invalid sort field %q", sortBy)
+	}
+
+	return problemSort{name: sortBy, descending: order == "desc", field: field}, nil
+}
+
+// direction returns the SQL sort direction keyword
+func (s problemSort) direction() string {
+	if s.descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// expr returns the sort expression, moving unset values to the end
+func (s problemSort) expr() string {
+	return s.moveUnset(s.field.expr)
+}
+
+// moveUnset wraps expr so that a 0 sorts last when the field asks for it
+func (s problemSort) moveUnset(expr string) string {
+	if !s.field.zeroLast {
+		return expr
+	}
+	last := unsetLastKey
+	if s.descending {
+		last = -1
+	}
+	return fmt.Sprintf("CASE WHEN %[1]s = 0 THEN %[2]d ELSE %[1]s END", expr, last)
+}
+
+// orderBy returns the ORDER BY clause, with the problem id as a tiebreaker
+func (s problemSort) orderBy() string {
+	if s.name == SortByRelevance {
+		return fmt.Sprintf("bm25(problems_fts, %s), p.created_at DESC, p.id DESC", searchRankWeights)
+	}
+	return fmt.Sprintf("%s %s, p.id %s", s.expr(), s.direction(), s.direction())
+}
+
+// cursorKey returns the expression selected for the keyset cursor
+func (s problemSort) cursorKey() string {
+	if s.name == SortByRelevance {
+		return "NULL"
+	}
+	return s.moveUnset(s.field.key)
+}
+
+// pageCursor is the decoded form of ProblemPage.NextCursor. Relevance order
+// has no stable key, so those cursors carry an offset instead.
+type pageCursor struct {
+	Sort   string      `json:"s"`
+	Desc   bool        `json:"d,omitempty"`
+	Value  interface{} `json:"v,omitempty"`
+	ID     int         `json:"id,omitempty"`
+	Offset int         `json:"o,omitempty"`
+}
+
+// encodeCursor serializes a cursor for ProblemPage.NextCursor
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor for sort, rejecting cursors that were made for
+// another ordering or altered
+func decodeCursor(s string, sort problemSort) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, models.NewError(models.ErrCodeValidation, "invalid cursor")
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, models.NewError(models.ErrCodeValidation, "invalid cursor")
+	}
+	if c.Sort != sort.name || c.Desc != sort.descending {
+		return nil, models.NewError(models.ErrCodeValidation, "cursor does not match the requested sort order")
+	}
+
+	// Keys are text or numbers; anything else was not produced by
+	// encodeCursor and cannot be bound as a query argument
+	valid := c.Offset >= 0
+	if sort.name != SortByRelevance {
+		switch c.Value.(type) {
+		case string, float64:
+			valid = valid && c.ID > 0
+		default:
+			valid = false
+		}
+	}
+	if !valid {
+		return nil, models.NewError(models.ErrCodeValidation, "invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// keysetCondition returns the condition selecting rows after the cursor
+func (s problemSort) keysetCondition(c *pageCursor) (string, []interface{}) {
+	op := ">"
+	if s.descending {
+		op = "<"
+	}
+	condition := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND p.id %[2]s ?))", s.expr(), op)
+	return condition, []interface{}{c.Value, c.Value, c.ID}
+}
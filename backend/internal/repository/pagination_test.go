@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// createPagingProblems imports problems whose values tie on every sort field
+func createPagingProblems(t *testing.T, repo *Repository) []int {
+	t.Helper()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	specs := []struct {
+		name, platform string
+		score, solve   int
+		created        int // days after base
+	}{
+		{"Alpha", "LeetCode", 1200, 10, 0},
+		{"alpha", "leetcode", 1200, 10, 0},
+		{"Beta", "Codeforces", 0, 0, 1},
+		{"beta", "AtCoder", 1600, 30, 1},
+		{"Gamma", "Codeforces", 1600, 30, 2},
+		{"Delta", "LeetCode", 0, 10, 2},
+		{"Epsilon", "AtCoder", 2000, 0, 2},
+	}
+
+	var problems []*models.Problem
+	for _, s := range specs {
+		at := models.CustomTime{Time: base.AddDate(0, 0, s.created)}
+		problems = append(problems, &models.Problem{Name: s.name, Platform: s.platform, Difficulty: "Medium",
+			DifficultyScore: s.score, SolveTime: s.solve, CreatedAt: at, UpdatedAt: at})
+	}
+	_, errs, err := repo.ImportProblems(problems, models.ImportKeepBoth, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("import %q: %v", problems[i].Name, err)
+		}
+		ids = append(ids, problems[i].ID)
+	}
+	return ids
+}
+
+// pageIDs returns the IDs of a page's problems
+func pageIDs(page *models.ProblemPage) []int {
+	var ids []int
+	for _, p := range page.Problems {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestGetProblemsPageCursor(t *testing.T) {
+	repo := newTestRepository(t)
+	ids := createPagingProblems(t, repo)
+
+	fields := []string{SortByCreatedAt, SortByUpdatedAt, SortByName, SortByDifficulty, SortBySolveTime, SortByPlatform}
+	for _, field := range fields {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(field+" "+order, func(t *testing.T) {
+				all, err := repo.GetProblemsPage(&models.ProblemFilter{SortBy: field, SortOrder: order, Limit: MaxPageSize})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := pageIDs(all)
+				if len(want) != len(ids) {
+					t.Fatalf("unpaged query returned %d problems, want %d", len(want), len(ids))
+				}
+
+				// Pages of two split every tie at least once
+				var got []int
+				cursor := ""
+				for pages := 0; ; pages++ {
+					if pages > len(ids) {
+						t.Fatalf("paging does not end; got %v", got)
+					}
+					page, err := repo.GetProblemsPage(&models.ProblemFilter{SortBy: field, SortOrder: order, Limit: 2, Cursor: cursor})
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, pageIDs(page)...)
+					if !page.HasMore {
+						break
+					}
+					cursor = page.NextCursor
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("paged order %v, want %v", got, want)
+				}
+			})
+		}
+	}
+
+	// Ties are broken by ID in the direction of the sort, and problems
+	// without a difficulty score come last either way
+	orders := []struct {
+		order string
+		want  []int
+	}{
+		{"asc", []int{ids[0], ids[1], ids[3], ids[4], ids[6], ids[2], ids[5]}},
+		{"desc", []int{ids[6], ids[4], ids[3], ids[1], ids[0], ids[5], ids[2]}},
+	}
+	for _, o := range orders {
+		page, err := repo.GetProblemsPage(&models.ProblemFilter{SortBy: SortByDifficulty, SortOrder: o.order, Limit: MaxPageSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(pageIDs(page), o.want) {
+			t.Errorf("difficulty %s order %v, want %v", o.order, pageIDs(page), o.want)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	byName := problemSort{name: SortByName, field: sortFields[SortByName]}
+	relevance := problemSort{name: SortByRelevance}
+	raw := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+
+	valid := encodeCursor(pageCursor{Sort: SortByName, Value: "Two Sum", ID: 7})
+	c, err := decodeCursor(valid, byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Value != "Two Sum" || c.ID != 7 {
+		t.Errorf("decoded %+v", c)
+	}
+	if c, err := decodeCursor(encodeCursor(pageCursor{Sort: SortByRelevance, Offset: 50}), relevance); err != nil || c.Offset != 50 {
+		t.Errorf("relevance cursor = %+v, %v", c, err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   problemSort
+	}{
+		{"not base64", "!!!", byName},
+		{"not JSON", raw("{"), byName},
+		{"other sort field", encodeCursor(pageCursor{Sort: SortByPlatform, Value: "x", ID: 1}), byName},
+		{"other direction", encodeCursor(pageCursor{Sort: SortByName, Desc: true, Value: "x", ID: 1}), byName},
+		{"object value", raw(`{"s":"name","v":{"a":1},"id":1}`), byName},
+		{"array value", raw(`{"s":"name","v":[1],"id":1}`), byName},
+		{"missing value", raw(`{"s":"name","id":1}`), byName},
+		{"missing ID", raw(`{"s":"name","v":"x"}`), byName},
+		{"negative ID", raw(`{"s":"name","v":"x","id":-1}`), byName},
+		{"negative offset", raw(`{"s":"relevance","o":-5}`), relevance},
+	}
+	for _, tt := range tests {
+		var appErr *models.Error
+		if _, err := decodeCursor(tt.cursor, tt.sort); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+			t.Errorf("%s: err = %v, want VALIDATION", tt.name, err)
+		}
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	cursor := &pageCursor{Value: float64(1600), ID: 4}
+	score := "CASE WHEN p.difficulty_score = 0 THEN %d ELSE p.difficulty_score END"
+	ascScore, descScore := fmt.Sprintf(score, unsetLastKey), fmt.Sprintf(score, -1)
+	tests := []struct {
+		field      string
+		descending bool
+		want       string
+	}{
+		{SortBySolveTime, false, "(p.solve_time > ? OR (p.solve_time = ? AND p.id > ?))"},
+		{SortBySolveTime, true, "(p.solve_time < ? OR (p.solve_time = ? AND p.id < ?))"},
+		{SortByDifficulty, false, "(" + ascScore + " > ? OR (" + ascScore + " = ? AND p.id > ?))"},
+		{SortByDifficulty, true, "(" + descScore + " < ? OR (" + descScore + " = ? AND p.id < ?))"},
+	}
+	for _, tt := range tests {
+		s := problemSort{name: tt.field, descending: tt.descending, field: sortFields[tt.field]}
+		condition, args := s.keysetCondition(cursor)
+		if condition != tt.want {
+			t.Errorf("%s descending=%v: condition %s, want %s", tt.field, tt.descending, condition, tt.want)
+		}
+		if len(args) != 3 || args[0] != float64(1600) || args[1] != float64(1600) || args[2] != 4 {
+			t.Errorf("%s descending=%v: args %v", tt.field, tt.descending, args)
+		}
+	}
+}
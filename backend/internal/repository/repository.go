@@ -8,21 +8,30 @@ import (
 
 	"github.com/algorithmtracker/backend/internal/database"
 	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/platform"
 )
 
+// ErrTagExists is returned when a tag name is already taken by another tag
+var ErrTagExists = models.NewError(models.ErrCodeConflict, "tag already exists")
+
+// ErrTagNotFound is returned when a tag ID does not exist
+var ErrTagNotFound = models.NewError(models.ErrCodeNotFound, "tag not found")
+
 // Repository handles data access operations
 type Repository struct {
-	db *sql.DB
+	db  *sql.DB
+	fts bool // full-text search index available
 }
 
-// NewRepository creates a new repository instance
-func NewRepository() *Repository {
+// NewRepository creates a repository on top of an open database handle
+func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		db: database.GetDB(),
+		db:  db,
+		fts: database.HasSearchIndex(db),
 	}
 }
 
-// CreateProblem creates a new problem record
+// CreateProblem creates a new problem record, solved unless it has a status
 func (r *Repository) CreateProblem(problem *models.Problem) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -32,14 +41,19 @@ func (r *Repository) CreateProblem(problem *models.Problem) error {
 
 	// Insert problem
 	now := models.CustomTime{Time: time.Now()}
+	if problem.Status == "" {
+		problem.Status = models.StatusSolved
+	}
+	setCanonicalID(problem)
 	result, err := tx.Exec(`
-		INSERT INTO problems (name, link, platform, difficulty, solve_time, notes, code_snippet, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.SolveTime, 
-	   problem.Notes, problem.CodeSnippet, now.Time, now.Time)
+		INSERT INTO problems (name, link, platform, difficulty, raw_difficulty, difficulty_score, solve_time, notes, code_snippet,
+		                      status, status_changed_at, canonical_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.RawDifficulty, problem.DifficultyScore,
+	   problem.SolveTime, problem.Notes, problem.CodeSnippet, problem.Status, now.Time, problem.CanonicalID, now.Time, now.Time)
 	
 	if err != nil {
-		return err
+		return canonicalIDConflict(err, problem)
 	}
 
 	problemID, err := result.LastInsertId()
@@ -47,6 +61,11 @@ func (r *Repository) CreateProblem(problem *models.Problem) error {
 		return err
 	}
 	problem.ID = int(problemID)
+	problem.StatusChangedAt = now
+
+	if err := recordStatusChange(tx, problem.ID, "", problem.Status, now.Time); err != nil {
+		return err
+	}
 
 	// Insert tags
 	for _, tag := range problem.Tags {
@@ -76,17 +95,37 @@ func (r *Repository) UpdateProblem(problem *models.Problem) error {
 	}
 	defer tx.Rollback()
 
+	// A status change is recorded in the history
+	var status string
+	err = tx.QueryRow("SELECT status, status_changed_at FROM problems WHERE id = ?", problem.ID).
+		Scan(&status, &problem.StatusChangedAt)
+	if err == sql.ErrNoRows {
+		return models.NewError(models.ErrCodeNotFound, "problem %d not found", problem.ID)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if problem.Status != status {
+		if err := recordStatusChange(tx, problem.ID, status, problem.Status, now); err != nil {
+			return err
+		}
+		problem.StatusChangedAt = models.CustomTime{Time: now}
+	}
+
 	// Update problem
+	setCanonicalID(problem)
 	_, err = tx.Exec(`
 		UPDATE problems 
-		SET name = ?, link = ?, platform = ?, difficulty = ?, solve_time = ?, 
-		    notes = ?, code_snippet = ?, updated_at = ?
+		SET name = ?, link = ?, platform = ?, difficulty = ?, raw_difficulty = ?, difficulty_score = ?, solve_time = ?, 
+		    notes = ?, code_snippet = ?, status = ?, status_changed_at = ?, canonical_id = ?, updated_at = ?
 		WHERE id = ?
-	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.SolveTime,
-	   problem.Notes, problem.CodeSnippet, time.Now(), problem.ID)
+	`, problem.Name, problem.Link, problem.Platform, problem.Difficulty, problem.RawDifficulty, problem.DifficultyScore,
+	   problem.SolveTime, problem.Notes, problem.CodeSnippet, problem.Status, problem.StatusChangedAt.Time,
+	   problem.CanonicalID, now, problem.ID)
 	
 	if err != nil {
-		return err
+		return canonicalIDConflict(err, problem)
 	}
 
 	// Delete existing tag associations
@@ -115,10 +154,29 @@ func (r *Repository) UpdateProblem(problem *models.Problem) error {
 	return tx.Commit()
 }
 
+// setCanonicalID sets the problem's canonical ID from its link, or clears it
+// when the link is not a problem of the problem's platform
+func setCanonicalID(problem *models.Problem) {
+	problem.CanonicalID = ""
+	if parsed, ok := platform.Parse(problem.Link); ok && parsed.Platform == platform.Canonical(problem.Platform) {
+		problem.CanonicalID = parsed.ID
+	}
+}
+
 // DeleteProblem deletes a problem by ID
 func (r *Repository) DeleteProblem(id int) error {
-	_, err := r.db.Exec("DELETE FROM problems WHERE id = ?", id)
-	return err
+	result, err := r.db.Exec("DELETE FROM problems WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return models.NewError(models.ErrCodeNotFound, "problem %d not found", id)
+	}
+	return nil
 }
 
 // GetProblem retrieves a problem by ID
@@ -126,11 +184,16 @@ func (r *Repository) GetProblem(id int) (*models.Problem, error) {
 	problem := &models.Problem{}
 	
 	err := r.db.QueryRow(`
-		SELECT id, name, link, platform, difficulty, solve_time, notes, code_snippet, created_at, updated_at
+		SELECT id, name, link, platform, difficulty, raw_difficulty, difficulty_score, solve_time, notes, code_snippet,
+		       status, status_changed_at, canonical_id, created_at, updated_at
 		FROM problems WHERE id = ?
 	`, id).Scan(&problem.ID, &problem.Name, &problem.Link, &problem.Platform, &problem.Difficulty,
-		&problem.SolveTime, &problem.Notes, &problem.CodeSnippet, &problem.CreatedAt, &problem.UpdatedAt)
+		&problem.RawDifficulty, &problem.DifficultyScore, &problem.SolveTime, &problem.Notes, &problem.CodeSnippet,
+		&problem.Status, &problem.StatusChangedAt, &problem.CanonicalID, &problem.CreatedAt, &problem.UpdatedAt)
 	
+	if err == sql.ErrNoRows {
+		return nil, models.WrapError(models.ErrCodeNotFound, err, "problem %d not found", id)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -145,29 +208,91 @@ func (r *Repository) GetProblem(id int) (*models.Problem, error) {
 	return problem, nil
 }
 
-// GetProblems retrieves problems with optional filtering
+// GetProblems retrieves problems with optional filtering. When a search query
+// is given, results are ordered by relevance and carry a highlighted snippet.
 func (r *Repository) GetProblems(filter *models.ProblemFilter) ([]models.Problem, error) {
-	query := `
-		SELECT DISTINCT p.id, p.name, p.link, p.platform, p.difficulty, p.solve_time, 
-		       p.notes, p.code_snippet, p.created_at, p.updated_at
-		FROM problems p
-	`
-	
+	page, err := r.queryProblems(filter, false)
+	if err != nil {
+		return nil, err
+	}
+	return page.Problems, nil
+}
+
+// GetProblemsPage retrieves one page of filtered problems together with the
+// total number of matches and a cursor for the next page
+func (r *Repository) GetProblemsPage(filter *models.ProblemFilter) (*models.ProblemPage, error) {
+	return r.queryProblems(filter, true)
+}
+
+// queryProblems runs a filtered problem query. Paged queries also count the
+// total number of matches and default to DefaultPageSize rows.
+func (r *Repository) queryProblems(filter *models.ProblemFilter, paged bool) (*models.ProblemPage, error) {
+	var joins []string
 	var conditions []string
 	var args []interface{}
+	snippet := "''"
+	searching := false
+
+	// Full-text search
+	if filter != nil && filter.SearchQuery != "" {
+		if r.fts {
+			if match := buildMatchQuery(filter.SearchQuery); match != "" {
+				joins = append(joins, "INNER JOIN problems_fts ON problems_fts.rowid = p.id")
+				conditions = append(conditions, "problems_fts MATCH ?")
+				args = append(args, match)
+				snippet = fmt.Sprintf("snippet(problems_fts, -1, '%s', '%s', '…', 16)", SnippetMatchStart, SnippetMatchEnd)
+				searching = true
+			}
+		} else {
+			for _, like := range likePatterns(filter.SearchQuery) {
+				conditions = append(conditions, `(p.name LIKE ? ESCAPE '\' OR p.notes LIKE ? ESCAPE '\'
+					OR p.code_snippet LIKE ? ESCAPE '\' OR EXISTS (
+					SELECT 1 FROM problem_tags spt
+					INNER JOIN tags st ON spt.tag_id = st.id
+					WHERE spt.problem_id = p.id AND st.name LIKE ? ESCAPE '\'
+				))`)
+				args = append(args, like, like, like, like)
+			}
+		}
+	}
 
-	// Join with tags if filtering by tags
+	// Tag filters. A tag matches problems tagged with it or any descendant.
 	if filter != nil && len(filter.Tags) > 0 {
-		query += `
-			INNER JOIN problem_tags pt ON p.id = pt.problem_id
-			INNER JOIN tags t ON pt.tag_id = t.id
-		`
-		placeholders := make([]string, len(filter.Tags))
-		for i, tag := range filter.Tags {
-			placeholders[i] = "?"
+		names := uniqueStrings(filter.Tags)
+		for _, tag := range names {
+			args = append(args, tag)
+		}
+
+		switch filter.TagMatch {
+		case "", models.TagMatchAny:
+			conditions = append(conditions, fmt.Sprintf(`p.id IN (
+				%s
+				SELECT pt.problem_id FROM problem_tags pt
+				INNER JOIN subtree s ON s.id = pt.tag_id
+			)`, tagSubtreeCTE(len(names))))
+		case models.TagMatchAll:
+			conditions = append(conditions, fmt.Sprintf(`p.id IN (
+				%s
+				SELECT pt.problem_id FROM problem_tags pt
+				INNER JOIN subtree s ON s.id = pt.tag_id
+				GROUP BY pt.problem_id
+				HAVING COUNT(DISTINCT s.root) = ?
+			)`, tagSubtreeCTE(len(names))))
+			args = append(args, len(names))
+		default:
+			return nil, models.NewError(models.ErrCodeValidation, "invalid tag match mode %q, use 'any' or 'all'", filter.TagMatch)
+		}
+	}
+	if filter != nil && len(filter.ExcludeTags) > 0 {
+		for _, tag := range filter.ExcludeTags {
 			args = append(args, tag)
 		}
-		conditions = append(conditions, fmt.Sprintf("t.name IN (%s)", strings.Join(placeholders, ",")))
+		conditions = append(conditions, fmt.Sprintf(`NOT EXISTS (
+			%s
+			SELECT 1 FROM problem_tags xpt
+			INNER JOIN subtree s ON s.id = xpt.tag_id
+			WHERE xpt.problem_id = p.id
+		)`, tagSubtreeCTE(len(filter.ExcludeTags))))
 	}
 
 	// Apply filters
@@ -176,13 +301,21 @@ func (r *Repository) GetProblems(filter *models.ProblemFilter) ([]models.Problem
 			conditions = append(conditions, "p.difficulty = ?")
 			args = append(args, filter.Difficulty)
 		}
+		if filter.MinScore > 0 {
+			conditions = append(conditions, "p.difficulty_score >= ?")
+			args = append(args, filter.MinScore)
+		}
+		if filter.MaxScore > 0 {
+			conditions = append(conditions, "p.difficulty_score <= ?")
+			args = append(args, filter.MaxScore)
+		}
 		if filter.Platform != "" {
 			conditions = append(conditions, "p.platform = ?")
 			args = append(args, filter.Platform)
 		}
-		if filter.SearchQuery != "" {
-			conditions = append(conditions, "p.name LIKE ?")
-			args = append(args, "%"+filter.SearchQuery+"%")
+		if filter.Status != "" {
+			conditions = append(conditions, "p.status = ?")
+			args = append(args, filter.Status)
 		}
 		if filter.StartDate != "" {
 			conditions = append(conditions, "p.created_at >= ?")
@@ -194,11 +327,74 @@ func (r *Repository) GetProblems(filter *models.ProblemFilter) ([]models.Problem
 		}
 	}
 
+	sort, err := resolveSort(filter, searching)
+	if err != nil {
+		return nil, err
+	}
+
+	from := "FROM problems p\n" + strings.Join(joins, "\n")
 	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+		from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := &models.ProblemPage{Problems: []models.Problem{}}
+
+	// Total count ignores the page window
+	if paged {
+		err := r.db.QueryRow("SELECT COUNT(*) "+from, args...).Scan(&page.Total)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Page window
+	limit, offset := 0, 0
+	if filter != nil {
+		limit, offset = filter.Limit, filter.Offset
+	}
+	if paged && limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if filter != nil && filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		if sort.name == SortByRelevance {
+			offset = cursor.Offset
+		} else {
+			condition, cursorArgs := sort.keysetCondition(cursor)
+			if len(conditions) > 0 {
+				from += " AND " + condition
+			} else {
+				from += " WHERE " + condition
+			}
+			args = append(args, cursorArgs...)
+			offset = 0
+		}
 	}
 
-	query += " ORDER BY p.created_at DESC"
+	query := fmt.Sprintf(`
+		SELECT p.id, p.name, p.link, p.platform, p.difficulty, p.raw_difficulty, p.difficulty_score, p.solve_time, 
+		       p.notes, p.code_snippet, p.status, p.status_changed_at, p.canonical_id, p.created_at, p.updated_at, %s, %s
+		%s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, snippet, sort.cursorKey(), from, sort.orderBy())
+
+	// Fetch one extra row to learn whether another page follows
+	if limit > 0 {
+		args = append(args, limit+1, offset)
+	} else {
+		args = append(args, -1, offset)
+	}
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
@@ -206,26 +402,45 @@ func (r *Repository) GetProblems(filter *models.ProblemFilter) ([]models.Problem
 	}
 	defer rows.Close()
 
-	var problems []models.Problem
+	var lastKey interface{}
 	for rows.Next() {
-		var p models.Problem
-		err := rows.Scan(&p.ID, &p.Name, &p.Link, &p.Platform, &p.Difficulty, &p.SolveTime,
-			&p.Notes, &p.CodeSnippet, &p.CreatedAt, &p.UpdatedAt)
-		if err != nil {
-			return nil, err
+		if limit > 0 && len(page.Problems) == limit {
+			page.HasMore = true
+			break
 		}
 
-		// Load tags for each problem
-		tags, err := r.getTagsForProblem(p.ID)
+		var p models.Problem
+		err := rows.Scan(&p.ID, &p.Name, &p.Link, &p.Platform, &p.Difficulty, &p.RawDifficulty, &p.DifficultyScore,
+			&p.SolveTime, &p.Notes, &p.CodeSnippet, &p.Status, &p.StatusChangedAt, &p.CanonicalID, &p.CreatedAt, &p.UpdatedAt,
+			&p.Snippet, &lastKey)
 		if err != nil {
 			return nil, err
 		}
-		p.Tags = tags
 
-		problems = append(problems, p)
+		page.Problems = append(page.Problems, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return problems, nil
+	// Release the connection before loading tags
+	rows.Close()
+	if err := r.loadTags(page.Problems); err != nil {
+		return nil, err
+	}
+
+	if page.HasMore {
+		next := pageCursor{Sort: sort.name, Desc: sort.descending}
+		if sort.name == SortByRelevance {
+			next.Offset = offset + limit
+		} else {
+			next.Value = lastKey
+			next.ID = page.Problems[len(page.Problems)-1].ID
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+
+	return page, nil
 }
 
 // CreateTag creates a new tag
@@ -253,7 +468,7 @@ func (r *Repository) CreateTag(name string) (*models.Tag, error) {
 
 // GetTags retrieves all tags
 func (r *Repository) GetTags() ([]models.Tag, error) {
-	rows, err := r.db.Query("SELECT id, name, created_at FROM tags ORDER BY name")
+	rows, err := r.db.Query("SELECT id, name, COALESCE(parent_id, 0), created_at FROM tags ORDER BY name")
 	if err != nil {
 		return nil, err
 	}
@@ -262,7 +477,7 @@ func (r *Repository) GetTags() ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -272,10 +487,133 @@ func (r *Repository) GetTags() ([]models.Tag, error) {
 	return tags, nil
 }
 
-// DeleteTag deletes a tag by ID
+// DeleteTag deletes a tag by ID. Its child tags move up to its parent.
 func (r *Repository) DeleteTag(id int) error {
-	_, err := r.db.Exec("DELETE FROM tags WHERE id = ?", id)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reparentTags(tx, map[int]bool{id: true}, 0); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM tags WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, id)
+	}
+
+	return tx.Commit()
+}
+
+// RenameTag renames a tag, keeping its problem associations
+func (r *Repository) RenameTag(id int, newName string) (*models.Tag, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tag := &models.Tag{}
+	err = tx.QueryRow("SELECT id, name, COALESCE(parent_id, 0), created_at FROM tags WHERE id = ?", id).
+		Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %d", ErrTagNotFound, id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var existingID int
+	err = tx.QueryRow("SELECT id FROM tags WHERE name = ? AND id != ?", newName, id).Scan(&existingID)
+	if err == nil {
+		return nil, fmt.Errorf("%w: %q (id %d)", ErrTagExists, newName, existingID)
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE tags SET name = ? WHERE id = ?", newName, id); err != nil {
+		return nil, err
+	}
+	tag.Name = newName
+
+	return tag, tx.Commit()
+}
+
+// MergeTags moves every problem association and child tag of the source tags
+// onto the target tag and deletes the sources, all in one transaction
+func (r *Repository) MergeTags(sourceIDs []int, targetID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tags WHERE id = ?", targetID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, targetID)
+	}
+
+	var placeholders []string
+	var args []interface{}
+	seen := map[int]bool{targetID: true}
+	for _, id := range sourceIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	if len(args) == 0 {
+		return tx.Commit()
+	}
+	in := strings.Join(placeholders, ",")
+
+	var found int
+	err = tx.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM tags WHERE id IN (%s)", in), args...).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found != len(args) {
+		return fmt.Errorf("%w: one or more source tags do not exist", ErrTagNotFound)
+	}
+
+	// Re-point associations, skipping problems that already carry the target
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT OR IGNORE INTO problem_tags (problem_id, tag_id)
+		SELECT problem_id, ? FROM problem_tags WHERE tag_id IN (%s)
+	`, in), append([]interface{}{targetID}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM problem_tags WHERE tag_id IN (%s)", in), args...); err != nil {
+		return err
+	}
+
+	removed := make(map[int]bool, len(args))
+	for _, id := range args {
+		removed[id.(int)] = true
+	}
+	if err := reparentTags(tx, removed, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM tags WHERE id IN (%s)", in), args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetStatistics retrieves problem statistics
@@ -283,7 +621,9 @@ func (r *Repository) GetStatistics() (*models.Statistics, error) {
 	stats := &models.Statistics{
 		ByDifficulty: make(map[string]int),
 		ByPlatform:   make(map[string]int),
+		ByStatus:     make(map[string]int),
 		ByTag:        make(map[string]int),
+		ByTagRollup:  make(map[string]int),
 	}
 
 	// Total problems
@@ -308,6 +648,11 @@ func (r *Repository) GetStatistics() (*models.Statistics, error) {
 		stats.ByDifficulty[difficulty] = count
 	}
 
+	// By difficulty score range
+	if stats.ByScoreRange, err = r.scoreBuckets(); err != nil {
+		return nil, err
+	}
+
 	// By platform
 	rows, err = r.db.Query("SELECT platform, COUNT(*) FROM problems GROUP BY platform")
 	if err != nil {
@@ -324,6 +669,25 @@ func (r *Repository) GetStatistics() (*models.Statistics, error) {
 		stats.ByPlatform[platform] = count
 	}
 
+	// By status, listing every status even without problems
+	for _, status := range models.ProblemStatuses {
+		stats.ByStatus[status] = 0
+	}
+	rows, err = r.db.Query("SELECT status, COUNT(*) FROM problems GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats.ByStatus[status] = count
+	}
+
 	// By tag
 	rows, err = r.db.Query(`
 		SELECT t.name, COUNT(DISTINCT pt.problem_id)
@@ -345,15 +709,78 @@ func (r *Repository) GetStatistics() (*models.Statistics, error) {
 		stats.ByTag[tagName] = count
 	}
 
+	// By tag, including descendant tags
+	rows, err = r.db.Query(`
+		WITH RECURSIVE subtree(root, id) AS (
+			SELECT id, id FROM tags
+			UNION
+			SELECT subtree.root, tags.id FROM tags INNER JOIN subtree ON tags.parent_id = subtree.id
+		)
+		SELECT t.name, COUNT(DISTINCT pt.problem_id)
+		FROM tags t
+		INNER JOIN subtree s ON s.root = t.id
+		LEFT JOIN problem_tags pt ON pt.tag_id = s.id
+		GROUP BY t.id, t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tagName string
+		var count int
+		if err := rows.Scan(&tagName, &count); err != nil {
+			return nil, err
+		}
+		stats.ByTagRollup[tagName] = count
+	}
+
 	// Average solve time
 	err = r.db.QueryRow("SELECT COALESCE(AVG(solve_time), 0.0) FROM problems WHERE solve_time > 0").Scan(&stats.AverageSolveTime)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
 
+	// Attempt history
+	if err := r.loadAttemptStatistics(stats); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
+// ScoreBucketWidth is the width of the difficulty score ranges in statistics
+const ScoreBucketWidth = 200
+
+// scoreBuckets counts scored problems per difficulty score range, from the
+// lowest to the highest non-empty range with empty ranges in between
+func (r *Repository) scoreBuckets() ([]models.ScoreBucket, error) {
+	rows, err := r.db.Query(`
+		SELECT (difficulty_score / ?) * ?, COUNT(*)
+		FROM problems WHERE difficulty_score > 0
+		GROUP BY 1 ORDER BY 1
+	`, ScoreBucketWidth, ScoreBucketWidth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []models.ScoreBucket{}
+	for rows.Next() {
+		var min, count int
+		if err := rows.Scan(&min, &count); err != nil {
+			return nil, err
+		}
+		for len(buckets) > 0 && buckets[len(buckets)-1].Max < min {
+			last := buckets[len(buckets)-1].Max
+			buckets = append(buckets, models.ScoreBucket{Min: last, Max: last + ScoreBucketWidth})
+		}
+		buckets = append(buckets, models.ScoreBucket{Min: min, Max: min + ScoreBucketWidth, Count: count})
+	}
+	return buckets, rows.Err()
+}
+
 // Helper functions
 
 func (r *Repository) getOrCreateTag(tx *sql.Tx, name string) (int, error) {
@@ -378,9 +805,135 @@ func (r *Repository) getOrCreateTag(tx *sql.Tx, name string) (int, error) {
 	return tagID, nil
 }
 
+// uniqueStrings returns values without duplicates, preserving order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// tagBatchSize bounds the number of ids bound into a single IN (...) list
+const tagBatchSize = 500
+
+// loadTags fills in the tags of every problem using one query per batch of
+// problems rather than one query per problem
+func (r *Repository) loadTags(problems []models.Problem) error {
+	index := make(map[int]int, len(problems))
+	for i := range problems {
+		index[problems[i].ID] = i
+		problems[i].Tags = nil
+	}
+
+	for start := 0; start < len(problems); start += tagBatchSize {
+		end := start + tagBatchSize
+		if end > len(problems) {
+			end = len(problems)
+		}
+
+		placeholders := make([]string, end-start)
+		args := make([]interface{}, end-start)
+		for i := start; i < end; i++ {
+			placeholders[i-start] = "?"
+			args[i-start] = problems[i].ID
+		}
+
+		rows, err := r.db.Query(fmt.Sprintf(`
+			SELECT pt.problem_id, t.id, t.name, COALESCE(t.parent_id, 0), t.created_at
+			FROM problem_tags pt
+			INNER JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.problem_id IN (%s)
+			ORDER BY t.name
+		`, strings.Join(placeholders, ",")), args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var problemID int
+			var tag models.Tag
+			if err := rows.Scan(&problemID, &tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			if i, ok := index[problemID]; ok {
+				problems[i].Tags = append(problems[i].Tags, tag)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetProblemsByIDs retrieves problems in the order of ids, skipping missing ones
+func (r *Repository) GetProblemsByIDs(ids []int) ([]models.Problem, error) {
+	found := make(map[int]models.Problem, len(ids))
+
+	for start := 0; start < len(ids); start += tagBatchSize {
+		end := start + tagBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		placeholders := make([]string, end-start)
+		args := make([]interface{}, end-start)
+		for i := start; i < end; i++ {
+			placeholders[i-start] = "?"
+			args[i-start] = ids[i]
+		}
+
+		rows, err := r.db.Query(fmt.Sprintf(`
+			SELECT id, name, link, platform, difficulty, raw_difficulty, difficulty_score, solve_time, notes, code_snippet,
+			       status, status_changed_at, canonical_id, created_at, updated_at
+			FROM problems WHERE id IN (%s)
+		`, strings.Join(placeholders, ",")), args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var p models.Problem
+			err := rows.Scan(&p.ID, &p.Name, &p.Link, &p.Platform, &p.Difficulty, &p.RawDifficulty, &p.DifficultyScore,
+				&p.SolveTime, &p.Notes, &p.CodeSnippet, &p.Status, &p.StatusChangedAt, &p.CanonicalID, &p.CreatedAt, &p.UpdatedAt)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			found[p.ID] = p
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	problems := make([]models.Problem, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := found[id]; ok {
+			problems = append(problems, p)
+		}
+	}
+
+	if err := r.loadTags(problems); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
 func (r *Repository) getTagsForProblem(problemID int) ([]models.Tag, error) {
 	rows, err := r.db.Query(`
-		SELECT t.id, t.name, t.created_at
+		SELECT t.id, t.name, COALESCE(t.parent_id, 0), t.created_at
 		FROM tags t
 		INNER JOIN problem_tags pt ON t.id = pt.tag_id
 		WHERE pt.problem_id = ?
@@ -395,7 +948,7 @@ func (r *Repository) getTagsForProblem(problemID int) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+const (
+	benchProblems       = 10000
+	benchTags           = 50
+	benchTagsPerProblem = 3
+)
+
+// newBenchRepository creates a database seeded with benchProblems problems,
+// each carrying benchTagsPerProblem of benchTags tags
+func newBenchRepository(b *testing.B) *Repository {
+	b.Helper()
+
+	db, err := database.Open(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	tx, err := db.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for t := 1; t <= benchTags; t++ {
+		if _, err := tx.Exec("INSERT INTO tags (id, name, created_at) VALUES (?, ?, ?)",
+			t, fmt.Sprintf("tag-%02d", t), now); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for p := 1; p <= benchProblems; p++ {
+		if _, err := tx.Exec(`
+			INSERT INTO problems (id, name, link, platform, difficulty, solve_time, notes, code_snippet, created_at, updated_at)
+			VALUES (?, ?, '', 'LeetCode', 'Medium', 30, '', '', ?, ?)
+		`, p, fmt.Sprintf("Problem %d", p), now, now); err != nil {
+			b.Fatal(err)
+		}
+		for k := 0; k < benchTagsPerProblem; k++ {
+			tagID := (p*7+k*13)%benchTags + 1
+			if _, err := tx.Exec("INSERT OR IGNORE INTO problem_tags (problem_id, tag_id) VALUES (?, ?)",
+				p, tagID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	return NewRepository(db)
+}
+
+// BenchmarkGetProblems measures GetProblems with batched tag loading
+func BenchmarkGetProblems(b *testing.B) {
+	repo := newBenchRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		problems, err := repo.GetProblems(nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(problems) != benchProblems {
+			b.Fatalf("got %d problems, want %d", len(problems), benchProblems)
+		}
+	}
+}
+
+// BenchmarkGetProblemsPerProblemTags measures the previous strategy of one
+// tag query per problem issued inside the row loop, as a baseline for
+// BenchmarkGetProblems
+func BenchmarkGetProblemsPerProblemTags(b *testing.B) {
+	repo := newBenchRepository(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rows, err := repo.db.Query(`
+			SELECT id, name, link, platform, difficulty, solve_time, notes, code_snippet, created_at, updated_at
+			FROM problems ORDER BY created_at DESC
+		`)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var problems []models.Problem
+		for rows.Next() {
+			var p models.Problem
+			err := rows.Scan(&p.ID, &p.Name, &p.Link, &p.Platform, &p.Difficulty, &p.SolveTime,
+				&p.Notes, &p.CodeSnippet, &p.CreatedAt, &p.UpdatedAt)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if p.Tags, err = repo.getTagsForProblem(p.ID); err != nil {
+				b.Fatal(err)
+			}
+			problems = append(problems, p)
+		}
+		rows.Close()
+
+		if len(problems) != benchProblems {
+			b.Fatalf("got %d problems, want %d", len(problems), benchProblems)
+		}
+	}
+}
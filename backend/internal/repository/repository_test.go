@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// newTestRepository creates a repository backed by a fresh database
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewRepository(db)
+}
+
+// createTestProblem inserts a problem with the given tags
+func createTestProblem(t *testing.T, repo *Repository, name string, tags ...string) *models.Problem {
+	t.Helper()
+
+	problem := &models.Problem{Name: name, Platform: "LeetCode", Difficulty: "Medium"}
+	for _, tag := range tags {
+		problem.Tags = append(problem.Tags, models.Tag{Name: tag})
+	}
+	if err := repo.CreateProblem(problem); err != nil {
+		t.Fatal(err)
+	}
+	return problem
+}
+
+// problemNames returns the sorted names of problems
+func problemNames(problems []models.Problem) []string {
+	names := []string{}
+	for _, p := range problems {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGetProblemsTagFilters(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "bfs grid", "graph", "bfs")
+	createTestProblem(t, repo, "dijkstra", "graph", "shortest path")
+	createTestProblem(t, repo, "knapsack", "dp")
+	createTestProblem(t, repo, "tsp", "dp", "bitmask")
+	createTestProblem(t, repo, "bitmask bfs", "graph", "bfs", "bitmask")
+	createTestProblem(t, repo, "untagged")
+
+	tests := []struct {
+		name    string
+		filter  models.ProblemFilter
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no tag filter",
+			want: []string{"bfs grid", "bitmask bfs", "dijkstra", "knapsack", "tsp", "untagged"},
+		},
+		{
+			name:   "any by default",
+			filter: models.ProblemFilter{Tags: []string{"dp", "bfs"}},
+			want:   []string{"bfs grid", "bitmask bfs", "knapsack", "tsp"},
+		},
+		{
+			name:   "any explicit",
+			filter: models.ProblemFilter{Tags: []string{"shortest path", "bitmask"}, TagMatch: models.TagMatchAny},
+			want:   []string{"bitmask bfs", "dijkstra", "tsp"},
+		},
+		{
+			name:   "all",
+			filter: models.ProblemFilter{Tags: []string{"graph", "bfs"}, TagMatch: models.TagMatchAll},
+			want:   []string{"bfs grid", "bitmask bfs"},
+		},
+		{
+			name:   "all with duplicate tag names",
+			filter: models.ProblemFilter{Tags: []string{"graph", "bfs", "graph"}, TagMatch: models.TagMatchAll},
+			want:   []string{"bfs grid", "bitmask bfs"},
+		},
+		{
+			name:   "all with unknown tag matches nothing",
+			filter: models.ProblemFilter{Tags: []string{"graph", "nonexistent"}, TagMatch: models.TagMatchAll},
+			want:   []string{},
+		},
+		{
+			name:   "exclude only",
+			filter: models.ProblemFilter{ExcludeTags: []string{"graph"}},
+			want:   []string{"knapsack", "tsp", "untagged"},
+		},
+		{
+			name:   "any with exclusion",
+			filter: models.ProblemFilter{Tags: []string{"dp"}, ExcludeTags: []string{"bitmask"}},
+			want:   []string{"knapsack"},
+		},
+		{
+			name:   "all with exclusion",
+			filter: models.ProblemFilter{Tags: []string{"graph", "bfs"}, TagMatch: models.TagMatchAll, ExcludeTags: []string{"bitmask"}},
+			want:   []string{"bfs grid"},
+		},
+		{
+			name:   "included and excluded tag cancel out",
+			filter: models.ProblemFilter{Tags: []string{"dp"}, ExcludeTags: []string{"dp"}},
+			want:   []string{},
+		},
+		{
+			name:    "invalid match mode",
+			filter:  models.ProblemFilter{Tags: []string{"dp"}, TagMatch: "some"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			problems, err := repo.GetProblems(&filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := problemNames(problems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetProblemsPageTagFilterTotal(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "a", "graph", "bfs")
+	createTestProblem(t, repo, "b", "graph", "bfs")
+	createTestProblem(t, repo, "c", "graph")
+
+	page, err := repo.GetProblemsPage(&models.ProblemFilter{
+		Tags:     []string{"graph", "bfs"},
+		TagMatch: models.TagMatchAll,
+		Limit:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || len(page.Problems) != 1 || !page.HasMore {
+		t.Errorf("got total=%d len=%d has_more=%v, want total=2 len=1 has_more=true",
+			page.Total, len(page.Problems), page.HasMore)
+	}
+}
+
+// tagIDs maps tag names to IDs
+func tagIDs(t *testing.T, repo *Repository) map[string]int {
+	t.Helper()
+
+	tags, err := repo.GetTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		ids[tag.Name] = tag.ID
+	}
+	return ids
+}
+
+func TestRenameTag(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "a", "grpah")
+	createTestProblem(t, repo, "b", "dp")
+	ids := tagIDs(t, repo)
+
+	tag, err := repo.RenameTag(ids["grpah"], "graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.ID != ids["grpah"] || tag.Name != "graph" {
+		t.Errorf("got %+v", tag)
+	}
+
+	problems, err := repo.GetProblems(&models.ProblemFilter{Tags: []string{"graph"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := problemNames(problems); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("renamed tag matches %v, want [a]", got)
+	}
+
+	if _, err := repo.RenameTag(ids["grpah"], "dp"); !errors.Is(err, ErrTagExists) {
+		t.Errorf("renaming onto an existing name: got %v, want ErrTagExists", err)
+	}
+	if _, err := repo.RenameTag(9999, "new"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("renaming a missing tag: got %v, want ErrTagNotFound", err)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "a", "dp")
+	createTestProblem(t, repo, "b", "DP", "dynamic programming")
+	createTestProblem(t, repo, "c", "dp", "DP")
+	createTestProblem(t, repo, "d", "graph")
+	ids := tagIDs(t, repo)
+
+	err := repo.MergeTags([]int{ids["DP"], ids["dynamic programming"], ids["dp"]}, ids["dp"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remaining := tagIDs(t, repo)
+	if len(remaining) != 2 || remaining["dp"] == 0 || remaining["graph"] == 0 {
+		t.Errorf("remaining tags %v, want dp and graph", remaining)
+	}
+
+	problems, err := repo.GetProblems(&models.ProblemFilter{Tags: []string{"dp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := problemNames(problems); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("merged tag matches %v, want [a b c]", got)
+	}
+	for _, p := range problems {
+		if len(p.Tags) != 1 {
+			t.Errorf("problem %s has tags %v, want only dp", p.Name, p.Tags)
+		}
+	}
+
+	if err := repo.MergeTags([]int{ids["graph"]}, 9999); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("merging into a missing tag: got %v, want ErrTagNotFound", err)
+	}
+}
+
+// setParent nests child under parent by name
+func setParent(t *testing.T, repo *Repository, child, parent string) {
+	t.Helper()
+
+	ids := tagIDs(t, repo)
+	if _, err := repo.SetTagParent(ids[child], ids[parent]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagHierarchy(t *testing.T) {
+	repo := newTestRepository(t)
+	createTestProblem(t, repo, "network delay", "dijkstra")
+	createTestProblem(t, repo, "cheapest flights", "bellman-ford", "dp")
+	createTestProblem(t, repo, "islands", "graph")
+	createTestProblem(t, repo, "coins", "dp")
+	if _, err := repo.CreateTag("shortest path"); err != nil {
+		t.Fatal(err)
+	}
+	setParent(t, repo, "shortest path", "graph")
+	setParent(t, repo, "dijkstra", "shortest path")
+	setParent(t, repo, "bellman-ford", "shortest path")
+	ids := tagIDs(t, repo)
+
+	t.Run("cycles are rejected", func(t *testing.T) {
+		if _, err := repo.SetTagParent(ids["graph"], ids["dijkstra"]); !errors.Is(err, ErrTagCycle) {
+			t.Errorf("got %v, want ErrTagCycle", err)
+		}
+		if _, err := repo.SetTagParent(ids["graph"], ids["graph"]); !errors.Is(err, ErrTagCycle) {
+			t.Errorf("got %v, want ErrTagCycle", err)
+		}
+	})
+
+	t.Run("filters include descendants", func(t *testing.T) {
+		tests := []struct {
+			filter models.ProblemFilter
+			want   []string
+		}{
+			{models.ProblemFilter{Tags: []string{"graph"}}, []string{"cheapest flights", "islands", "network delay"}},
+			{models.ProblemFilter{Tags: []string{"shortest path"}}, []string{"cheapest flights", "network delay"}},
+			{models.ProblemFilter{Tags: []string{"graph", "dp"}, TagMatch: models.TagMatchAll}, []string{"cheapest flights"}},
+			{models.ProblemFilter{Tags: []string{"dp"}, ExcludeTags: []string{"graph"}}, []string{"coins"}},
+		}
+		for _, tt := range tests {
+			filter := tt.filter
+			problems, err := repo.GetProblems(&filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := problemNames(problems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%+v: got %v, want %v", tt.filter, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("statistics roll up", func(t *testing.T) {
+		stats, err := repo.GetStatistics()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.ByTag["graph"] != 1 || stats.ByTagRollup["graph"] != 3 || stats.ByTagRollup["shortest path"] != 2 {
+			t.Errorf("by_tag=%v by_tag_rollup=%v", stats.ByTag, stats.ByTagRollup)
+		}
+	})
+
+	t.Run("deleting a tag lifts its children", func(t *testing.T) {
+		if err := repo.DeleteTag(ids["shortest path"]); err != nil {
+			t.Fatal(err)
+		}
+		tags, err := repo.GetTags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tag := range tags {
+			if (tag.Name == "dijkstra" || tag.Name == "bellman-ford") && tag.ParentID != ids["graph"] {
+				t.Errorf("%s has parent %d, want graph (%d)", tag.Name, tag.ParentID, ids["graph"])
+			}
+		}
+	})
+}
+
+func TestMergeTagsMovesChildren(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, name := range []string{"graph", "graphs", "bfs", "dfs"} {
+		if _, err := repo.CreateTag(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setParent(t, repo, "bfs", "graphs")
+	setParent(t, repo, "dfs", "graphs")
+	setParent(t, repo, "graph", "dfs")
+	ids := tagIDs(t, repo)
+
+	// graph sits below one of the source's children, which must not become its child
+	if err := repo.MergeTags([]int{ids["graphs"]}, ids["graph"]); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := repo.GetTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents := make(map[string]int)
+	for _, tag := range tags {
+		parents[tag.Name] = tag.ParentID
+	}
+	want := map[string]int{"graph": ids["dfs"], "bfs": ids["graph"], "dfs": 0}
+	if !reflect.DeepEqual(parents, want) {
+		t.Errorf("got parents %v, want %v", parents, want)
+	}
+}
+
+func TestDeleteMissing(t *testing.T) {
+	repo := newTestRepository(t)
+	problem := createTestProblem(t, repo, "a", "dp")
+	attempt := &models.Attempt{ProblemID: problem.ID, Verdict: models.VerdictAccepted}
+	if err := repo.CreateAttempt(attempt, nil); err != nil {
+		t.Fatal(err)
+	}
+	tagID := tagIDs(t, repo)["dp"]
+
+	deletes := []struct {
+		name   string
+		delete func() error
+	}{
+		{"attempt", func() error { return repo.DeleteAttempt(attempt.ID) }},
+		{"tag", func() error { return repo.DeleteTag(tagID) }},
+		{"problem", func() error { return repo.DeleteProblem(problem.ID) }},
+	}
+	for _, d := range deletes {
+		if err := d.delete(); err != nil {
+			t.Fatalf("delete %s: %v", d.name, err)
+		}
+		var appErr *models.Error
+		if err := d.delete(); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeNotFound {
+			t.Errorf("delete %s again: err = %v, want NOT_FOUND", d.name, err)
+		}
+	}
+}
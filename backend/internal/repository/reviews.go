@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// tagCondition restricts a query on problems aliased as p to a single tag
+// and its descendants
+var tagCondition = fmt.Sprintf(`p.id IN (
+	%s
+	SELECT pt.problem_id FROM problem_tags pt
+	INNER JOIN subtree s ON s.id = pt.tag_id
+)`, tagSubtreeCTE(1))
+
+// GetReviewState retrieves the review schedule of a problem. It returns nil
+// without an error when the problem has never been reviewed.
+func (r *Repository) GetReviewState(problemID int) (*models.ReviewState, error) {
+	state := &models.ReviewState{}
+	err := r.db.QueryRow(`
+		SELECT problem_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at
+		FROM reviews WHERE problem_id = ?
+	`, problemID).Scan(&state.ProblemID, &state.EaseFactor, &state.IntervalDays, &state.Repetitions,
+		&state.Lapses, &state.DueAt, &state.LastReviewedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// SaveReviewState inserts or replaces the review schedule of a problem
+func (r *Repository) SaveReviewState(state *models.ReviewState) error {
+	_, err := r.db.Exec(`
+		INSERT INTO reviews (problem_id, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(problem_id) DO UPDATE SET
+			ease_factor = excluded.ease_factor,
+			interval_days = excluded.interval_days,
+			repetitions = excluded.repetitions,
+			lapses = excluded.lapses,
+			due_at = excluded.due_at,
+			last_reviewed_at = excluded.last_reviewed_at
+	`, state.ProblemID, state.EaseFactor, state.IntervalDays, state.Repetitions, state.Lapses,
+		state.DueAt.UTC(), state.LastReviewedAt.UTC())
+	return err
+}
+
+// GetDueReviews retrieves problems due for review at the given time, most
+// overdue first, followed by problems that have never been reviewed. An empty
+// tag matches every problem; a non-positive limit returns all due problems.
+func (r *Repository) GetDueReviews(now time.Time, limit int, tag string) ([]models.DueReview, error) {
+	query := `
+		SELECT p.id, r.problem_id, r.ease_factor, r.interval_days, r.repetitions, r.lapses,
+		       r.due_at, r.last_reviewed_at
+		FROM problems p
+		LEFT JOIN reviews r ON r.problem_id = p.id
+		WHERE (r.problem_id IS NULL OR r.due_at <= ?)
+	`
+	args := []interface{}{now.UTC()}
+
+	if tag != "" {
+		query += " AND " + tagCondition
+		args = append(args, tag)
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+	query += " ORDER BY r.problem_id IS NULL, r.due_at, p.created_at LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		problemIDs []int
+		states     = make(map[int]*models.ReviewState)
+	)
+	for rows.Next() {
+		var (
+			problemID      int
+			stateProblemID sql.NullInt64
+			state          models.ReviewState
+			ease           sql.NullFloat64
+			interval       sql.NullInt64
+			repetitions    sql.NullInt64
+			lapses         sql.NullInt64
+		)
+		err := rows.Scan(&problemID, &stateProblemID, &ease, &interval, &repetitions, &lapses,
+			&state.DueAt, &state.LastReviewedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		problemIDs = append(problemIDs, problemID)
+		if !stateProblemID.Valid {
+			continue
+		}
+
+		state.ProblemID = problemID
+		state.EaseFactor = ease.Float64
+		state.IntervalDays = int(interval.Int64)
+		state.Repetitions = int(repetitions.Int64)
+		state.Lapses = int(lapses.Int64)
+		states[problemID] = &state
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	problems, err := r.GetProblemsByIDs(problemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]models.DueReview, len(problems))
+	for i, problem := range problems {
+		reviews[i] = models.DueReview{Problem: problem, State: states[problem.ID]}
+	}
+
+	return reviews, nil
+}
+
+// GetReviewDueTimes retrieves the due time of every scheduled review falling
+// due before the given time, optionally restricted to a tag
+func (r *Repository) GetReviewDueTimes(before time.Time, tag string) ([]time.Time, error) {
+	query := `
+		SELECT r.due_at
+		FROM reviews r
+		INNER JOIN problems p ON p.id = r.problem_id
+		WHERE r.due_at < ?
+	`
+	args := []interface{}{before.UTC()}
+
+	if tag != "" {
+		query += " AND " + tagCondition
+		args = append(args, tag)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dueTimes []time.Time
+	for rows.Next() {
+		var due models.CustomTime
+		if err := rows.Scan(&due); err != nil {
+			return nil, err
+		}
+		dueTimes = append(dueTimes, due.Time)
+	}
+
+	return dueTimes, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Markers wrapped around matched terms in search snippets
+const (
+	SnippetMatchStart = "<mark>"
+	SnippetMatchEnd   = "</mark>"
+)
+
+// searchRankWeights weights bm25 by column: name, notes, code_snippet, tags
+const searchRankWeights = "10.0, 2.0, 1.0, 5.0"
+
+// searchTerm is a word or double-quoted phrase of a search query
+type searchTerm struct {
+	text   string
+	prefix bool // the word ended in *
+}
+
+// parseSearchTerms splits user search input into words and phrases.
+// Double-quoted text is one phrase; an unterminated quote runs to the end.
+func parseSearchTerms(input string) []searchTerm {
+	var terms []searchTerm
+
+	for len(input) > 0 {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		if input == "" {
+			break
+		}
+
+		if input[0] == '"' {
+			end := strings.IndexByte(input[1:], '"')
+			var phrase string
+			if end < 0 {
+				phrase, input = input[1:], ""
+			} else {
+				phrase, input = input[1:end+1], input[end+2:]
+			}
+			terms = append(terms, searchTerm{text: phrase})
+			continue
+		}
+
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		var word string
+		if end < 0 {
+			word, input = input, ""
+		} else {
+			word, input = input[:end], input[end:]
+		}
+		terms = append(terms, searchTerm{text: strings.TrimRight(word, "*"), prefix: strings.HasSuffix(word, "*")})
+	}
+
+	return terms
+}
+
+// buildMatchQuery converts user search input into a safe FTS5 MATCH
+// expression. Double-quoted text is searched as a phrase, a trailing * makes a
+// word a prefix query, and all other words must match. Everything else is
+// quoted so FTS5 operators in the input can never cause syntax errors.
+func buildMatchQuery(input string) string {
+	var terms []string
+	for _, t := range parseSearchTerms(input) {
+		if term := quoteTerm(t.text); term != "" {
+			if t.prefix {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// quoteTerm wraps text in an FTS5 string literal, or returns "" if it holds no
+// searchable characters
+func quoteTerm(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.IndexFunc(text, isSearchable) < 0 {
+		return ""
+	}
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// likePatterns converts user search input into LIKE patterns for searching
+// without the FTS5 index, one per word or phrase, all of which must match.
+// The FTS5 syntax is dropped so "dijk*" still finds "Dijkstra": quotes, the
+// prefix star and punctuation around words. The patterns escape LIKE's
+// wildcards with a backslash.
+func likePatterns(input string) []string {
+	var patterns []string
+	for _, t := range parseSearchTerms(input) {
+		text := strings.TrimFunc(t.text, func(r rune) bool { return !isSearchable(r) })
+		if text == "" {
+			continue
+		}
+		text = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+		patterns = append(patterns, "%"+text+"%")
+	}
+	return patterns
+}
+
+// isSearchable reports whether r is indexed by the search tokenizer
+func isSearchable(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
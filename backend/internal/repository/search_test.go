@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"dijkstra", `"dijkstra"`},
+		{"shortest path", `"shortest" "path"`},
+		{`"shortest path" graph`, `"shortest path" "graph"`},
+		{`"unterminated phrase`, `"unterminated phrase"`},
+		{"dijk*", `"dijk"*`},
+		{"dp**", `"dp"*`},
+		{"a OR b", `"a" "OR" "b"`},
+		{"NOT graph", `"NOT" "graph"`},
+		{"name:two -sum (x)", `"name:two" "-sum" "(x)"`},
+		{`say"hi"`, `"say""hi"""`},
+		{`* - "" ()`, ""},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := buildMatchQuery(tt.input); got != tt.want {
+			t.Errorf("buildMatchQuery(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLikePatterns(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"dijk*", []string{"%dijk%"}},
+		{`"two sum" (graph)`, []string{"%two sum%", "%graph%"}},
+		{"100% a_b", []string{"%100%", `%a\_b%`}},
+		{`c\d`, []string{`%c\\d%`}},
+		{"* - ()", nil},
+	}
+
+	for _, tt := range tests {
+		if got := likePatterns(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("likePatterns(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// searchNames returns the sorted names of problems matching a search query
+func searchNames(t *testing.T, repo *Repository, query string) []string {
+	t.Helper()
+
+	problems, err := repo.GetProblems(&models.ProblemFilter{SearchQuery: query})
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+	return problemNames(problems)
+}
+
+func TestSearchWithoutIndex(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.fts = false
+	createTestProblem(t, repo, "Dijkstra", "graph")
+	createTestProblem(t, repo, "Two Sum", "hash_map")
+	createTestProblem(t, repo, "Knapsack", "dp")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"dijk*", []string{"Dijkstra"}},
+		{`"two sum"`, []string{"Two Sum"}},
+		{"sum two", []string{"Two Sum"}},
+		{"graph OR dp", []string{}},
+		{"hash_map", []string{"Two Sum"}},
+		{"hash%map", []string{}},
+		{"*", []string{"Dijkstra", "Knapsack", "Two Sum"}},
+	}
+
+	for _, tt := range tests {
+		if got := searchNames(t, repo, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	repo := newTestRepository(t)
+	if !repo.fts {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+	dijkstra := createTestProblem(t, repo, "Dijkstra", "graph")
+	createTestProblem(t, repo, "Shortest Path Visiting All Nodes", "graph", "bitmask")
+	createTestProblem(t, repo, "Path Sum", "tree")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"dijkstra", []string{"Dijkstra"}},
+		{"dijk*", []string{"Dijkstra"}},
+		{`"shortest path"`, []string{"Shortest Path Visiting All Nodes"}},
+		{`"path shortest"`, []string{}},
+		{"path", []string{"Path Sum", "Shortest Path Visiting All Nodes"}},
+		{"bitmask", []string{"Shortest Path Visiting All Nodes"}},
+		{"graph NOT bitmask", []string{}},
+	}
+	for _, tt := range tests {
+		if got := searchNames(t, repo, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// Updates replace the indexed text
+	dijkstra.Name = "Network Delay Time"
+	dijkstra.Notes = "heap"
+	if err := repo.UpdateProblem(dijkstra); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, repo, "dijkstra"); len(got) != 0 {
+		t.Errorf("old name still matches %v", got)
+	}
+	if got := searchNames(t, repo, "network heap"); !reflect.DeepEqual(got, []string{"Network Delay Time"}) {
+		t.Errorf("updated problem search = %v", got)
+	}
+
+	// Renamed tags are reindexed on every problem carrying them
+	if _, err := repo.RenameTag(tagIDs(t, repo)["graph"], "graphs"); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, repo, "graphs"); len(got) != 2 {
+		t.Errorf("renamed tag matches %v, want 2 problems", got)
+	}
+
+	// Deletes remove the problem's row from the index
+	if err := repo.DeleteProblem(dijkstra.ID); err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	if err := repo.db.QueryRow("SELECT COUNT(*) FROM problems_fts WHERE rowid = ?", dijkstra.ID).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 0 {
+		t.Errorf("deleted problem has %d index rows", rows)
+	}
+	if got := searchNames(t, repo, "network"); len(got) != 0 {
+		t.Errorf("deleted problem still matches %v", got)
+	}
+}
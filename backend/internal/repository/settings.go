@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GetSetting returns the stored value of a setting and whether it is set
+func (r *Repository) GetSetting(key string) (string, bool, error) {
+	var value string
+	err := r.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// SetSetting stores the value of a setting, replacing any previous value
+func (r *Repository) SetSetting(key, value string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, time.Now())
+	return err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// recordStatusChange appends a status transition to a problem's history
+func recordStatusChange(tx *sql.Tx, problemID int, from, to string, at time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO problem_status_history (problem_id, from_status, to_status, changed_at)
+		VALUES (?, ?, ?, ?)
+	`, problemID, from, to, at)
+	return err
+}
+
+// SetProblemStatus moves a problem to status at the given time and records
+// the transition. Setting the current status again changes nothing.
+func (r *Repository) SetProblemStatus(problemID int, status string, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow("SELECT status FROM problems WHERE id = ?", problemID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return models.NewError(models.ErrCodeNotFound, "problem %d not found", problemID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := setProblemStatus(tx, problemID, current, status, at); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setProblemStatus moves a problem from status current to status within tx,
+// recording the transition. Nothing changes when the status is the same.
+func setProblemStatus(tx *sql.Tx, problemID int, current, status string, at time.Time) error {
+	if current == status {
+		return nil
+	}
+
+	if _, err := tx.Exec("UPDATE problems SET status = ?, status_changed_at = ?, updated_at = ? WHERE id = ?",
+		status, at, time.Now(), problemID); err != nil {
+		return err
+	}
+	return recordStatusChange(tx, problemID, current, status, at)
+}
+
+// GetStatusHistory retrieves the status transitions of a problem, oldest
+// first
+func (r *Repository) GetStatusHistory(problemID int) ([]models.StatusChange, error) {
+	rows, err := r.db.Query(`
+		SELECT problem_id, from_status, to_status, changed_at
+		FROM problem_status_history
+		WHERE problem_id = ?
+		ORDER BY changed_at, id
+	`, problemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.StatusChange{}
+	for rows.Next() {
+		var c models.StatusChange
+		if err := rows.Scan(&c.ProblemID, &c.FromStatus, &c.ToStatus, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+// GetStatusChangesTo retrieves every transition into one of statuses, oldest
+// first
+func (r *Repository) GetStatusChangesTo(statuses ...string) ([]models.StatusChange, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = status
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT problem_id, from_status, to_status, changed_at
+		FROM problem_status_history
+		WHERE to_status IN (%s)
+		ORDER BY changed_at, id
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.StatusChange
+	for rows.Next() {
+		var c models.StatusChange
+		if err := rows.Scan(&c.ProblemID, &c.FromStatus, &c.ToStatus, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// ErrTagCycle is returned when a parent assignment would make a tag its own ancestor
+var ErrTagCycle = models.NewError(models.ErrCodeValidation, "tag cannot be nested under itself or its descendants")
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// placeholders returns a comma-separated list of n bind parameters
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// tagSubtreeCTE returns a recursive CTE named subtree(root, id) that pairs
+// each of n tag names bound as parameters with the IDs of that tag and all
+// of its descendants
+func tagSubtreeCTE(n int) string {
+	return fmt.Sprintf(`WITH RECURSIVE subtree(root, id) AS (
+		SELECT name, id FROM tags WHERE name IN (%s)
+		UNION
+		SELECT subtree.root, tags.id FROM tags INNER JOIN subtree ON tags.parent_id = subtree.id
+	)`, placeholders(n))
+}
+
+// SetTagParent nests a tag under another one. A parentID of 0 makes the tag
+// top-level.
+func (r *Repository) SetTagParent(id, parentID int) (*models.Tag, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	parents, err := loadTagParents(tx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parents[id]; !ok {
+		return nil, fmt.Errorf("%w: %d", ErrTagNotFound, id)
+	}
+
+	var parent interface{}
+	if parentID != 0 {
+		if _, ok := parents[parentID]; !ok {
+			return nil, fmt.Errorf("%w: %d", ErrTagNotFound, parentID)
+		}
+		if isTagAncestor(parents, id, parentID) {
+			return nil, ErrTagCycle
+		}
+		parent = parentID
+	}
+
+	if _, err := tx.Exec("UPDATE tags SET parent_id = ? WHERE id = ?", parent, id); err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{}
+	err = tx.QueryRow("SELECT id, name, COALESCE(parent_id, 0), created_at FROM tags WHERE id = ?", id).
+		Scan(&tag.ID, &tag.Name, &tag.ParentID, &tag.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, tx.Commit()
+}
+
+// GetTagCounts returns, per tag ID, the number of problems tagged directly
+// and the number tagged with the tag or any of its descendants
+func (r *Repository) GetTagCounts() (direct, rollup map[int]int, err error) {
+	direct = make(map[int]int)
+	rollup = make(map[int]int)
+
+	rows, err := r.db.Query(`
+		WITH RECURSIVE subtree(root, id) AS (
+			SELECT id, id FROM tags
+			UNION
+			SELECT subtree.root, tags.id FROM tags INNER JOIN subtree ON tags.parent_id = subtree.id
+		)
+		SELECT s.root,
+		       COUNT(DISTINCT CASE WHEN s.id = s.root THEN pt.problem_id END),
+		       COUNT(DISTINCT pt.problem_id)
+		FROM subtree s
+		LEFT JOIN problem_tags pt ON pt.tag_id = s.id
+		GROUP BY s.root
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, directCount, rollupCount int
+		if err := rows.Scan(&id, &directCount, &rollupCount); err != nil {
+			return nil, nil, err
+		}
+		direct[id] = directCount
+		rollup[id] = rollupCount
+	}
+
+	return direct, rollup, rows.Err()
+}
+
+// loadTagParents maps every tag ID to its parent ID (0 for top-level tags)
+func loadTagParents(q querier) (map[int]int, error) {
+	rows, err := q.Query("SELECT id, COALESCE(parent_id, 0) FROM tags")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := make(map[int]int)
+	for rows.Next() {
+		var id, parentID int
+		if err := rows.Scan(&id, &parentID); err != nil {
+			return nil, err
+		}
+		parents[id] = parentID
+	}
+
+	return parents, rows.Err()
+}
+
+// isTagAncestor reports whether ancestor is tag itself or one of its ancestors
+func isTagAncestor(parents map[int]int, ancestor, tag int) bool {
+	for steps := 0; tag != 0 && steps <= len(parents); steps++ {
+		if tag == ancestor {
+			return true
+		}
+		tag = parents[tag]
+	}
+	return false
+}
+
+// reparentTags rewrites parent links after the removed tags are deleted.
+// Children of a removed tag move to adoptiveParent when given, unless that
+// would create a cycle; otherwise they move to their nearest surviving
+// ancestor.
+func reparentTags(tx *sql.Tx, removed map[int]bool, adoptiveParent int) error {
+	parents, err := loadTagParents(tx)
+	if err != nil {
+		return err
+	}
+
+	// nearestSurvivor walks up past removed tags
+	nearestSurvivor := func(id int) int {
+		for steps := 0; id != 0 && removed[id] && steps <= len(parents); steps++ {
+			id = parents[id]
+		}
+		return id
+	}
+
+	updated := make(map[int]int, len(parents))
+	for id, parentID := range parents {
+		updated[id] = parentID
+		if removed[id] || !removed[parentID] {
+			continue
+		}
+		updated[id] = nearestSurvivor(parentID)
+	}
+
+	if adoptiveParent != 0 {
+		// The adoptive parent itself must first escape any removed ancestors
+		updated[adoptiveParent] = nearestSurvivor(parents[adoptiveParent])
+
+		for id, parentID := range parents {
+			if removed[id] || id == adoptiveParent || !removed[parentID] {
+				continue
+			}
+			if !isTagAncestor(updated, id, adoptiveParent) {
+				updated[id] = adoptiveParent
+			}
+		}
+	}
+
+	for id, parentID := range updated {
+		if removed[id] || parentID == parents[id] {
+			continue
+		}
+		var parent interface{}
+		if parentID != 0 {
+			parent = parentID
+		}
+		if _, err := tx.Exec("UPDATE tags SET parent_id = ? WHERE id = ?", parent, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
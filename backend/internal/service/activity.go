@@ -0,0 +1,248 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // zone database for systems without one, such as Windows
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+const (
+	// timezoneSettingsKey is the settings row holding the IANA timezone used
+	// to split activity into days
+	timezoneSettingsKey = "timezone"
+
+	// heatmapDays is the length of the activity heatmap, ending today
+	heatmapDays = 365
+
+	// maxActivityPeriods caps the length of the activity series, which is
+	// about ten years of days
+	maxActivityPeriods = 3660
+
+	dateLayout = "2006-01-02"
+)
+
+// GetTimezone returns the stored timezone name, or "" when activity uses the
+// zone the process runs in
+func (s *Service) GetTimezone() (string, error) {
+	value, ok, err := s.repo.GetSetting(timezoneSettingsKey)
+	if err != nil || !ok {
+		return "", err
+	}
+	var name string
+	if err := json.Unmarshal([]byte(value), &name); err != nil {
+		return "", fmt.Errorf("invalid stored timezone: %w", err)
+	}
+	return name, nil
+}
+
+// SetTimezone validates and stores the timezone name. An empty name goes
+// back to the zone the process runs in.
+func (s *Service) SetTimezone(name string) error {
+	name = strings.TrimSpace(name)
+	if _, err := loadTimezone(name); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetSetting(timezoneSettingsKey, string(value))
+}
+
+// loadTimezone loads an IANA timezone, or the process's zone for ""
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, models.NewValidationError([]models.FieldError{
+			{Field: "timezone", Message: fmt.Sprintf("unknown timezone %q", name)},
+		})
+	}
+	return loc, nil
+}
+
+// GetActivityStatistics returns the solve activity time series, heatmap,
+// streaks and rolling counts. Days are split in the options' timezone, or
+// else in the timezone setting.
+func (s *Service) GetActivityStatistics(options *models.ActivityOptions) (*models.ActivityStatistics, error) {
+	return s.activityStatistics(options, time.Now())
+}
+
+func (s *Service) activityStatistics(options *models.ActivityOptions, now time.Time) (*models.ActivityStatistics, error) {
+	opts := models.ActivityOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Granularity == "" {
+		opts.Granularity = models.GranularityDay
+	}
+
+	var fields []models.FieldError
+	switch opts.Granularity {
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth:
+	default:
+		fields = append(fields, models.FieldError{Field: "granularity", Message: "granularity must be day, week, or month"})
+	}
+	if opts.Periods < 0 {
+		fields = append(fields, models.FieldError{Field: "periods", Message: "periods cannot be negative"})
+	} else if opts.Periods > maxActivityPeriods {
+		fields = append(fields, models.FieldError{
+			Field:   "periods",
+			Message: fmt.Sprintf("periods cannot exceed %d", maxActivityPeriods),
+		})
+	}
+	if len(fields) > 0 {
+		return nil, models.NewValidationError(fields)
+	}
+
+	if opts.Timezone == "" {
+		name, err := s.GetTimezone()
+		if err != nil {
+			return nil, err
+		}
+		opts.Timezone = name
+	}
+	loc, err := loadTimezone(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repo.GetStatusChangesTo(models.StatusSolved, models.StatusMastered)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count each problem once per day it was solved. A problem created as
+	// mastered or mastered on a revisit was solved then too, but moving a
+	// solved problem on to mastered is not another solve. Days are civil
+	// dates, kept as UTC midnights so that day arithmetic ignores DST.
+	type solve struct {
+		problemID int
+		day       time.Time
+	}
+	seen := make(map[solve]bool)
+	daily := make(map[time.Time]int)
+	var first time.Time
+	for _, c := range changes {
+		if c.ToStatus == models.StatusMastered && c.FromStatus == models.StatusSolved {
+			continue
+		}
+		key := solve{c.ProblemID, civilDay(c.ChangedAt.Time, loc)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		daily[key.day]++
+		if first.IsZero() || key.day.Before(first) {
+			first = key.day
+		}
+	}
+	today := civilDay(now, loc)
+
+	stats := &models.ActivityStatistics{
+		Timezone:    loc.String(),
+		Granularity: opts.Granularity,
+		Series:      []models.ActivityPoint{},
+		TotalSolved: len(seen),
+	}
+
+	for day := today.AddDate(0, 0, 1-heatmapDays); !day.After(today); day = day.AddDate(0, 0, 1) {
+		stats.Heatmap = append(stats.Heatmap, models.ActivityPoint{Date: day.Format(dateLayout), Count: daily[day]})
+	}
+	for i := 0; i < 30; i++ {
+		count := daily[today.AddDate(0, 0, -i)]
+		if i < 7 {
+			stats.Last7Days += count
+		}
+		stats.Last30Days += count
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = solveStreaks(daily, today)
+
+	end := periodStart(today, opts.Granularity)
+	start := end
+	if opts.Periods > 0 {
+		start = addPeriods(end, opts.Granularity, 1-opts.Periods)
+	} else if !first.IsZero() {
+		// Start at the first solve, but no further back than the longest
+		// series allowed
+		start = periodStart(first, opts.Granularity)
+		if earliest := addPeriods(end, opts.Granularity, 1-maxActivityPeriods); start.Before(earliest) {
+			start = earliest
+		}
+	}
+	if opts.Periods > 0 || !first.IsZero() {
+		index := make(map[time.Time]int)
+		for p := start; !p.After(end); p = addPeriods(p, opts.Granularity, 1) {
+			index[p] = len(stats.Series)
+			stats.Series = append(stats.Series, models.ActivityPoint{Date: p.Format(dateLayout)})
+		}
+		for day, count := range daily {
+			if i, ok := index[periodStart(day, opts.Granularity)]; ok {
+				stats.Series[i].Count += count
+			}
+		}
+	}
+	return stats, nil
+}
+
+// civilDay returns the date of t in loc as a UTC midnight
+func civilDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// periodStart returns the first day of the day, week or month holding day
+func periodStart(day time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.GranularityWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case models.GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// addPeriods moves a period start n days, weeks or months
+func addPeriods(start time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case models.GranularityWeek:
+		return start.AddDate(0, 0, 7*n)
+	case models.GranularityMonth:
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// solveStreaks returns the current and the longest run of consecutive days
+// with a solve. The current streak still counts when today has no solve yet,
+// as long as yesterday had one.
+func solveStreaks(daily map[time.Time]int, today time.Time) (current, longest int) {
+	for day := range daily {
+		// Only count runs from their first day
+		if daily[day.AddDate(0, 0, -1)] > 0 {
+			continue
+		}
+		run := 0
+		for d := day; daily[d] > 0; d = d.AddDate(0, 0, 1) {
+			run++
+		}
+		longest = max(longest, run)
+	}
+
+	day := today
+	if daily[day] == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+	for ; daily[day] > 0; day = day.AddDate(0, 0, -1) {
+		current++
+	}
+	return current, longest
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// solveAt creates a problem on the backlog and solves it at each given time,
+// marking it for a revisit in between
+func solveAt(t *testing.T, svc *Service, times ...string) {
+	t.Helper()
+
+	problem := &models.Problem{Name: fmt.Sprintf("Solved %s", times[0]), Platform: "LeetCode",
+		Difficulty: "Easy", Status: models.StatusTodo}
+	createProblems(t, svc, problem)
+	for i, value := range times {
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			if err := svc.repo.SetProblemStatus(problem.ID, models.StatusRevisit, at.Add(-time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := svc.repo.SetProblemStatus(problem.ID, models.StatusSolved, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestActivityStatistics(t *testing.T) {
+	svc := newTestService(t)
+	solveAt(t, svc, "2026-03-09T23:30:00Z") // March 10 in Berlin
+	solveAt(t, svc, "2026-03-09T12:00:00Z", "2026-03-09T18:00:00Z")
+	solveAt(t, svc, "2026-03-08T12:00:00Z")
+	solveAt(t, svc, "2026-03-01T08:00:00Z")
+	solveAt(t, svc, "2026-03-01T09:00:00Z")
+	for _, day := range []string{"20", "21", "22", "23"} {
+		solveAt(t, svc, "2026-02-"+day+"T12:00:00Z")
+	}
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	if err := svc.SetTimezone("Mars/Olympus_Mons"); err == nil {
+		t.Error("stored an unknown timezone")
+	}
+	if err := svc.SetTimezone("Europe/Berlin"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := svc.activityStatistics(&models.ActivityOptions{Granularity: models.GranularityWeek}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Timezone != "Europe/Berlin" || stats.TotalSolved != 9 {
+		t.Errorf("timezone %q, total %d", stats.Timezone, stats.TotalSolved)
+	}
+	if stats.CurrentStreak != 3 || stats.LongestStreak != 4 {
+		t.Errorf("streaks: current %d, longest %d; want 3, 4", stats.CurrentStreak, stats.LongestStreak)
+	}
+	if stats.Last7Days != 3 || stats.Last30Days != 9 {
+		t.Errorf("rolling counts: 7 days %d, 30 days %d; want 3, 9", stats.Last7Days, stats.Last30Days)
+	}
+	wantSeries := []models.ActivityPoint{
+		{Date: "2026-02-16", Count: 3},
+		{Date: "2026-02-23", Count: 3},
+		{Date: "2026-03-02", Count: 1},
+		{Date: "2026-03-09", Count: 2},
+	}
+	if fmt.Sprint(stats.Series) != fmt.Sprint(wantSeries) {
+		t.Errorf("weekly series = %v, want %v", stats.Series, wantSeries)
+	}
+	if n := len(stats.Heatmap); n != heatmapDays || stats.Heatmap[n-1] != (models.ActivityPoint{Date: "2026-03-10", Count: 1}) {
+		t.Errorf("heatmap has %d days ending %+v", n, stats.Heatmap[n-1])
+	}
+
+	// In UTC nothing was solved today yet, which keeps yesterday's streak
+	stats, err = svc.activityStatistics(&models.ActivityOptions{Granularity: models.GranularityMonth, Periods: 3, Timezone: "UTC"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CurrentStreak != 2 {
+		t.Errorf("UTC current streak = %d, want 2", stats.CurrentStreak)
+	}
+	wantSeries = []models.ActivityPoint{
+		{Date: "2026-01-01", Count: 0},
+		{Date: "2026-02-01", Count: 4},
+		{Date: "2026-03-01", Count: 5},
+	}
+	if fmt.Sprint(stats.Series) != fmt.Sprint(wantSeries) {
+		t.Errorf("monthly series = %v, want %v", stats.Series, wantSeries)
+	}
+
+	var appErr *models.Error
+	_, err = svc.activityStatistics(&models.ActivityOptions{Granularity: "year", Periods: -1}, now)
+	if !errors.As(err, &appErr) || len(appErr.Fields) != 2 {
+		t.Errorf("invalid options: err = %v, want 2 field errors", err)
+	}
+	_, err = svc.activityStatistics(&models.ActivityOptions{Periods: maxActivityPeriods + 1}, now)
+	if !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("too many periods: err = %v, want VALIDATION", err)
+	}
+	stats, err = svc.activityStatistics(&models.ActivityOptions{Periods: maxActivityPeriods}, now)
+	if err != nil || len(stats.Series) != maxActivityPeriods {
+		t.Errorf("maximum periods: %d points, err %v", len(stats.Series), err)
+	}
+}
+
+func TestActivityCountsMasteredSolves(t *testing.T) {
+	svc := newTestService(t)
+	mastered := &models.Problem{Name: "Mastered", Platform: "LeetCode", Difficulty: "Easy", Status: models.StatusMastered}
+	revisited := &models.Problem{Name: "Revisited", Platform: "LeetCode", Difficulty: "Easy", Status: models.StatusTodo}
+	createProblems(t, svc, mastered, revisited)
+
+	// Solving and then mastering is one solve; mastering on a revisit is another
+	steps := []struct {
+		status string
+		at     string
+	}{
+		{models.StatusSolved, "2026-03-07T12:00:00Z"},
+		{models.StatusMastered, "2026-03-08T12:00:00Z"},
+		{models.StatusRevisit, "2026-03-09T11:00:00Z"},
+		{models.StatusMastered, "2026-03-09T12:00:00Z"},
+	}
+	for _, step := range steps {
+		at, err := time.Parse(time.RFC3339, step.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.repo.SetProblemStatus(revisited.ID, step.status, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	stats, err := svc.activityStatistics(&models.ActivityOptions{Timezone: "UTC"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalSolved != 3 {
+		t.Errorf("total solved = %d, want 3", stats.TotalSolved)
+	}
+	if n := len(stats.Heatmap); stats.Heatmap[n-1].Count != 1 {
+		t.Errorf("today's count = %d, want the problem created as mastered", stats.Heatmap[n-1].Count)
+	}
+}
+
+func TestActivitySeriesStartIsCapped(t *testing.T) {
+	svc := newTestService(t)
+	solveAt(t, svc, "1970-01-02T00:00:00Z", "2026-03-09T12:00:00Z")
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	stats, err := svc.activityStatistics(&models.ActivityOptions{Timezone: "UTC"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(stats.Series); n != maxActivityPeriods || stats.Series[n-1] != (models.ActivityPoint{Date: "2026-03-10"}) {
+		t.Fatalf("daily series has %d points ending %+v, want %d ending today", n, stats.Series[n-1], maxActivityPeriods)
+	}
+	if stats.TotalSolved != 2 || stats.Series[maxActivityPeriods-2].Count != 1 {
+		t.Errorf("total %d, yesterday %+v", stats.TotalSolved, stats.Series[maxActivityPeriods-2])
+	}
+
+	// Coarser series reach further back within the same cap
+	stats, err = svc.activityStatistics(&models.ActivityOptions{Granularity: models.GranularityMonth, Timezone: "UTC"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Series) != 675 || stats.Series[0] != (models.ActivityPoint{Date: "1970-01-01", Count: 1}) {
+		t.Errorf("monthly series has %d points starting %+v", len(stats.Series), stats.Series[0])
+	}
+}
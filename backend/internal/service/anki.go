@@ -0,0 +1,496 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+const (
+	defaultAnkiDeck = "Algorithm Problems"
+
+	// ankiModelID identifies the note type; it is fixed so that decks
+	// exported at different times share one note type in Anki
+	ankiModelID = 1617104372931
+
+	// ankiFieldSeparator separates the fields of a note in notes.flds
+	ankiFieldSeparator = "\x1f"
+)
+
+// ankiFields are the fields of the exported note type, in order
+var ankiFields = []string{"Name", "Link", "Tags", "Notes", "Code"}
+
+const ankiFront = `<div class="name">{{Name}}</div>
+{{#Link}}<div class="link"><a href="{{Link}}">{{Link}}</a></div>{{/Link}}
+{{#Tags}}<div class="tags">{{Tags}}</div>{{/Tags}}`
+
+const ankiBack = `{{FrontSide}}
+<hr id="answer">
+{{#Notes}}<div class="notes">{{Notes}}</div>{{/Notes}}
+{{#Code}}<pre class="code"><code>{{Code}}</code></pre>{{/Code}}`
+
+const ankiCSS = `.card { font-family: sans-serif; font-size: 18px; text-align: left; }
+.name { font-size: 24px; font-weight: bold; }
+.tags { color: #666; font-size: 14px; }
+.code { font-family: monospace; font-size: 14px; white-space: pre; overflow-x: auto; }`
+
+// ankiCollectionSchema is the legacy (schema 11) collection layout, which
+// every Anki version imports
+const ankiCollectionSchema = `
+CREATE TABLE col (
+	id integer primary key, crt integer not null, mod integer not null,
+	scm integer not null, ver integer not null, dty integer not null,
+	usn integer not null, ls integer not null, conf text not null,
+	models text not null, decks text not null, dconf text not null,
+	tags text not null
+);
+CREATE TABLE notes (
+	id integer primary key, guid text not null, mid integer not null,
+	mod integer not null, usn integer not null, tags text not null,
+	flds text not null, sfld integer not null, csum integer not null,
+	flags integer not null, data text not null
+);
+CREATE TABLE cards (
+	id integer primary key, nid integer not null, did integer not null,
+	ord integer not null, mod integer not null, usn integer not null,
+	type integer not null, queue integer not null, due integer not null,
+	ivl integer not null, factor integer not null, reps integer not null,
+	lapses integer not null, left integer not null, odue integer not null,
+	odid integer not null, flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+	id integer primary key, cid integer not null, usn integer not null,
+	ease integer not null, ivl integer not null, lastIvl integer not null,
+	factor integer not null, time integer not null, type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// ankiNote is one exported problem, rendered as HTML fields
+type ankiNote struct {
+	GUID   string
+	Fields []string // in ankiFields order
+	Tags   []string
+}
+
+// ExportAnki writes the problems matching the options' filter as an Anki
+// deck: either a .apkg package that Anki imports as a ready-made deck, or
+// tab-separated text for File > Import. Each card shows the name, link and
+// tags on the front and the notes and code on the back; problem tags become
+// Anki tags, with sub-tags nested as Parent::Child.
+func (s *Service) ExportAnki(filePath string, options *models.AnkiOptions) error {
+	opts, err := normalizeAnkiOptions(options)
+	if err != nil {
+		return err
+	}
+
+	problems, err := s.GetProblems(opts.Filter)
+	if err != nil {
+		return err
+	}
+	tags, err := s.repo.GetTags()
+	if err != nil {
+		return err
+	}
+
+	paths := ankiTagPaths(tags)
+	notes := make([]ankiNote, 0, len(problems))
+	for _, p := range problems {
+		notes = append(notes, newAnkiNote(p, paths))
+	}
+
+	if opts.Format == models.AnkiText {
+		return writeAnkiText(filePath, opts.DeckName, notes)
+	}
+	return writeAnkiPackage(filePath, opts.DeckName, notes)
+}
+
+// normalizeAnkiOptions fills in defaults and validates the options
+func normalizeAnkiOptions(options *models.AnkiOptions) (*models.AnkiOptions, error) {
+	opts := models.AnkiOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Format == "" {
+		opts.Format = models.AnkiPackage
+	}
+	opts.DeckName = strings.TrimSpace(opts.DeckName)
+	if opts.DeckName == "" {
+		opts.DeckName = defaultAnkiDeck
+	}
+
+	if opts.Format != models.AnkiPackage && opts.Format != models.AnkiText {
+		return nil, models.NewValidationError([]models.FieldError{
+			{Field: "format", Message: "format must be apkg or anki_text"},
+		})
+	}
+	return &opts, nil
+}
+
+// ankiTagPaths maps tag IDs to Anki tag names: the tag's ancestors joined
+// by "::" with whitespace replaced, since Anki tags cannot contain spaces
+func ankiTagPaths(tags []models.Tag) map[int]string {
+	byID := make(map[int]models.Tag, len(tags))
+	for _, tag := range tags {
+		byID[tag.ID] = tag
+	}
+
+	paths := make(map[int]string, len(tags))
+	for _, tag := range tags {
+		parts := []string{ankiTagName(tag.Name)}
+		// The depth bound guards against a corrupted parent cycle
+		for parent, depth := tag.ParentID, 0; parent != 0 && depth < len(tags); depth++ {
+			p, ok := byID[parent]
+			if !ok {
+				break
+			}
+			parts = append([]string{ankiTagName(p.Name)}, parts...)
+			parent = p.ParentID
+		}
+		paths[tag.ID] = strings.Join(parts, "::")
+	}
+	return paths
+}
+
+var ankiTagSpace = regexp.MustCompile(`\s+`)
+
+func ankiTagName(name string) string {
+	return ankiTagSpace.ReplaceAllString(strings.TrimSpace(name), "_")
+}
+
+// newAnkiNote renders a problem into note fields. The GUID is derived from
+// the problem's link (or platform and name) so re-importing an updated
+// export updates the existing notes instead of duplicating them.
+func newAnkiNote(p models.Problem, paths map[int]string) ankiNote {
+	tags := make([]string, 0, len(p.Tags))
+	names := make([]string, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		path, ok := paths[tag.ID]
+		if !ok {
+			path = ankiTagName(tag.Name)
+		}
+		tags = append(tags, path)
+		names = append(names, html.EscapeString(tag.Name))
+	}
+
+	name := html.EscapeString(p.Name)
+	if p.Platform != "" || p.Difficulty != "" {
+		var meta []string
+		for _, v := range []string{p.Platform, p.Difficulty} {
+			if v != "" {
+				meta = append(meta, html.EscapeString(v))
+			}
+		}
+		name += ` <span class="meta">(` + strings.Join(meta, ", ") + `)</span>`
+	}
+
+	identity := p.Link
+	if identity == "" {
+		identity = p.Platform + "\x00" + p.Name
+	}
+	sum := sha1.Sum([]byte("algorithm-tracker:" + identity))
+
+	return ankiNote{
+		GUID: hex.EncodeToString(sum[:10]),
+		Fields: []string{
+			name,
+			html.EscapeString(p.Link),
+			strings.Join(names, ", "),
+			strings.ReplaceAll(html.EscapeString(p.Notes), "\n", "<br>"),
+			html.EscapeString(p.CodeSnippet),
+		},
+		Tags: tags,
+	}
+}
+
+// writeAnkiText writes notes in Anki's tab-separated import format. The
+// header lines tell Anki the separator, that fields are HTML, the note type
+// and deck to use, and which columns hold the GUID and the tags.
+func writeAnkiText(filePath, deckName string, notes []ankiNote) error {
+	var b strings.Builder
+	b.WriteString("#separator:tab\n#html:true\n#notetype:Basic\n")
+	b.WriteString("#deck:" + deckName + "\n")
+	b.WriteString("#guid column:1\n#tags column:4\n")
+	for _, note := range notes {
+		front := ankiTextField(ankiRender(ankiFront, note))
+		back := ankiTextField(ankiRender(ankiBack, note))
+		b.WriteString(note.GUID + "\t" + front + "\t" + back + "\t" + strings.Join(note.Tags, " ") + "\n")
+	}
+	return os.WriteFile(filePath, []byte(b.String()), 0o644)
+}
+
+// ankiTextField keeps an HTML field on one line of the text format
+func ankiTextField(s string) string {
+	s = strings.ReplaceAll(s, "\t", "&#9;")
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.ReplaceAll(s, "\n", "&#10;")
+}
+
+var ankiSection = regexp.MustCompile(`(?s)\{\{#(\w+)\}\}(.*?)\{\{/\w+\}\}`)
+
+// ankiRender expands the subset of Anki's template syntax used by the
+// card templates, for the text format where Anki's basic note type shows
+// the fields as-is
+func ankiRender(template string, note ankiNote) string {
+	values := make(map[string]string, len(ankiFields)+1)
+	for i, field := range ankiFields {
+		values[field] = note.Fields[i]
+	}
+	values["FrontSide"] = ""
+
+	out := ankiSection.ReplaceAllStringFunc(template, func(section string) string {
+		m := ankiSection.FindStringSubmatch(section)
+		if values[m[1]] == "" {
+			return ""
+		}
+		return m[2]
+	})
+	for field, value := range values {
+		out = strings.ReplaceAll(out, "{{"+field+"}}", value)
+	}
+	// The text format has separate front and back fields, so the back does
+	// not repeat the front and drops the leading separator
+	out = strings.TrimPrefix(strings.TrimSpace(out), `<hr id="answer">`)
+	return strings.TrimSpace(out)
+}
+
+// writeAnkiPackage builds an Anki collection holding one deck and zips it
+// into a .apkg package
+func writeAnkiPackage(filePath, deckName string, notes []ankiNote) error {
+	tmpDir, err := os.MkdirTemp("", "anki-export-")
+	if err != nil {
+		return models.WrapError(models.ErrCodeIO, err, "cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	collection := filepath.Join(tmpDir, "collection.anki2")
+	if err := writeAnkiCollection(collection, deckName, notes, time.Now()); err != nil {
+		return err
+	}
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	err = addZipFile(zw, "collection.anki2", collection)
+	if err == nil {
+		// The media manifest maps numbered zip entries to file names; the
+		// deck has no media
+		var w io.Writer
+		if w, err = zw.Create("media"); err == nil {
+			_, err = w.Write([]byte("{}"))
+		}
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return models.WrapError(models.ErrCodeIO, err, "cannot write Anki package: %v", err)
+	}
+	return nil
+}
+
+func addZipFile(zw *zip.Writer, name, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// writeAnkiCollection creates the collection database at path with one
+// note type, one deck and a new card per note
+func writeAnkiCollection(path, deckName string, notes []ankiNote, now time.Time) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ankiCollectionSchema); err != nil {
+		return models.WrapError(models.ErrCodeInternal, err, "cannot create Anki collection: %v", err)
+	}
+
+	deckID := ankiDeckID(deckName)
+	nowMs := now.UnixMilli()
+	conf, noteTypes, decks, dconf, err := ankiCollectionConfig(deckID, deckName, now.Unix())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), nowMs, nowMs, conf, noteTypes, decks, dconf)
+	if err != nil {
+		return err
+	}
+
+	for i, note := range notes {
+		// Note and card IDs are creation times in milliseconds and must be
+		// unique, so consecutive notes get consecutive IDs
+		id := nowMs + int64(i)
+		sortField := ankiStripHTML(note.Fields[0])
+		tags := ""
+		if len(note.Tags) > 0 {
+			tags = " " + strings.Join(note.Tags, " ") + " "
+		}
+		_, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			id, note.GUID, ankiModelID, now.Unix(), tags,
+			strings.Join(note.Fields, ankiFieldSeparator), sortField, ankiChecksum(sortField))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			id, id, deckID, now.Unix(), i+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// ankiCollectionConfig returns the JSON columns of the col row: collection
+// config, note types, decks and deck options
+func ankiCollectionConfig(deckID int64, deckName string, mod int64) (conf, noteTypes, decks, dconf string, err error) {
+	fields := make([]map[string]interface{}, len(ankiFields))
+	for i, name := range ankiFields {
+		fields[i] = map[string]interface{}{
+			"name": name, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		}
+	}
+
+	model := map[string]interface{}{
+		"id":        ankiModelID,
+		"name":      "Algorithm Problem",
+		"type":      0,
+		"mod":       mod,
+		"usn":       -1,
+		"sortf":     0,
+		"did":       deckID,
+		"flds":      fields,
+		"css":       ankiCSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"tags":      []string{},
+		"vers":      []int{},
+		"req":       []interface{}{[]interface{}{0, "any", []int{0}}},
+		"tmpls": []map[string]interface{}{{
+			"name": "Card 1", "ord": 0, "qfmt": ankiFront, "afmt": ankiBack,
+			"did": nil, "bqfmt": "", "bafmt": "",
+		}},
+	}
+
+	deck := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "desc": "", "mod": mod, "usn": -1,
+			"conf": 1, "dyn": 0, "collapsed": false,
+			"extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0},
+			"lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+
+	options := map[string]interface{}{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0,
+		"maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true,
+		"new": map[string]interface{}{
+			"delays": []float64{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+			"order": 1, "perDay": 20, "separate": true, "bury": true,
+		},
+		"rev": map[string]interface{}{
+			"perDay": 100, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1,
+			"maxIvl": 36500, "minSpace": 1, "bury": true,
+		},
+		"lapse": map[string]interface{}{
+			"delays": []float64{10}, "mult": 0, "minInt": 1,
+			"leechFails": 8, "leechAction": 0,
+		},
+	}
+
+	collection := map[string]interface{}{
+		"activeDecks": []int64{deckID}, "curDeck": deckID, "curModel": ankiModelID,
+		"nextPos": 1, "newSpread": 0, "collapseTime": 1200, "timeLim": 0,
+		"estTimes": true, "dueCounts": true, "sortType": "noteFld",
+		"sortBackwards": false, "addToCur": true,
+	}
+
+	values := []interface{}{
+		collection,
+		map[string]interface{}{fmt.Sprint(ankiModelID): model},
+		map[string]interface{}{"1": deck(1, "Default"), fmt.Sprint(deckID): deck(deckID, deckName)},
+		map[string]interface{}{"1": options},
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		out[i] = string(data)
+	}
+	return out[0], out[1], out[2], out[3], nil
+}
+
+// ankiDeckID derives a stable deck ID from the deck name, so repeated
+// exports land in the same deck
+func ankiDeckID(name string) int64 {
+	sum := sha1.Sum([]byte(name))
+	// Keep the ID positive and clear of the default deck's ID 1
+	return int64(binary.BigEndian.Uint64(sum[:8])>>12) + 2
+}
+
+var ankiHTMLTag = regexp.MustCompile(`<[^>]*>`)
+
+// ankiStripHTML returns the plain text of a field, as Anki stores it for
+// sorting and duplicate checks
+func ankiStripHTML(s string) string {
+	return strings.TrimSpace(html.UnescapeString(ankiHTMLTag.ReplaceAllString(s, "")))
+}
+
+// ankiChecksum is Anki's first-field checksum: the first 8 hex digits of
+// the SHA-1 of the stripped field
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
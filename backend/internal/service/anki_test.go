@@ -0,0 +1,168 @@
+package service
+
+import (
+	"archive/zip"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// unpackCollection extracts collection.anki2 from a package and opens it
+func unpackCollection(t *testing.T, apkg string) *sql.DB {
+	t.Helper()
+
+	zr, err := zip.OpenReader(apkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	entries := map[string]bool{}
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	for _, f := range zr.File {
+		entries[f.Name] = true
+		if f.Name != "collection.anki2" {
+			continue
+		}
+		in, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			t.Fatal(err)
+		}
+		in.Close()
+		out.Close()
+	}
+	if !entries["collection.anki2"] || !entries["media"] {
+		t.Fatalf("package entries = %v, want collection.anki2 and media", entries)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestExportAnkiPackage(t *testing.T) {
+	svc := newTestService(t)
+	seedNotebook(t, svc)
+
+	tags, err := svc.GetTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	for _, tag := range tags {
+		ids[tag.Name] = tag.ID
+	}
+	if _, err := svc.SetTagParent(ids["Shortest Paths"], ids["Graphs"]); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "deck.apkg")
+	options := &models.AnkiOptions{DeckName: "Graphs", Filter: &models.ProblemFilter{Tags: []string{"Graphs"}}}
+	if err := svc.ExportAnki(path, options); err != nil {
+		t.Fatal(err)
+	}
+
+	db := unpackCollection(t, path)
+
+	var decks string
+	if err := db.QueryRow("SELECT decks FROM col").Scan(&decks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(decks, `"name":"Graphs"`) {
+		t.Errorf("decks = %s, want a Graphs deck", decks)
+	}
+
+	rows, err := db.Query("SELECT n.tags, n.flds, n.sfld FROM notes n JOIN cards c ON c.nid = n.id ORDER BY n.sfld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var notes [][3]string
+	for rows.Next() {
+		var note [3]string
+		if err := rows.Scan(&note[0], &note[1], &note[2]); err != nil {
+			t.Fatal(err)
+		}
+		notes = append(notes, note)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("exported %d notes, want the 2 tagged Graphs", len(notes))
+	}
+
+	if got := notes[1][2]; got != "Shortest Routes I (CSES, Medium)" {
+		t.Errorf("sort field = %q", got)
+	}
+	if got := notes[1][0]; got != " Graphs Graphs::Shortest_Paths " {
+		t.Errorf("tags = %q, want nested Anki tags", got)
+	}
+	fields := strings.Split(notes[1][1], "\x1f")
+	if len(fields) != len(ankiFields) {
+		t.Fatalf("note has %d fields, want %d", len(fields), len(ankiFields))
+	}
+	if fields[1] != "https://cses.fi/problemset/task/1671" {
+		t.Errorf("link field = %q", fields[1])
+	}
+	if fields[3] != "Dijkstra with a &lt;min-heap&gt;" {
+		t.Errorf("notes field = %q, want escaped HTML", fields[3])
+	}
+	if !strings.HasPrefix(fields[4], "#include &lt;bits/stdc++.h&gt;") {
+		t.Errorf("code field = %q", fields[4])
+	}
+}
+
+func TestExportAnkiText(t *testing.T) {
+	svc := newTestService(t)
+	seedNotebook(t, svc)
+
+	path := filepath.Join(t.TempDir(), "deck.txt")
+	if err := svc.ExportAnki(path, &models.AnkiOptions{Format: models.AnkiText}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(readFile(t, path), "\n"), "\n")
+	if lines[0] != "#separator:tab" || lines[3] != "#deck:Algorithm Problems" {
+		t.Errorf("header = %q", lines[:6])
+	}
+
+	var notes []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			notes = append(notes, line)
+		}
+	}
+	if len(notes) != 3 {
+		t.Fatalf("exported %d notes, want 3", len(notes))
+	}
+	for _, note := range notes {
+		columns := strings.Split(note, "\t")
+		if len(columns) != 4 {
+			t.Fatalf("note has %d columns, want guid, front, back and tags: %q", len(columns), note)
+		}
+		if strings.Contains(columns[1], "Markdown in code") && !strings.Contains(columns[2], "return &#34;```&#34;") {
+			t.Errorf("back = %q, want the escaped code on one line", columns[2])
+		}
+	}
+
+	var apiErr *models.Error
+	err := svc.ExportAnki(path, &models.AnkiOptions{Format: "pdf"})
+	if !errors.As(err, &apiErr) || apiErr.Code != models.ErrCodeValidation {
+		t.Errorf("unknown format error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// exportJSON exports svc to a file and returns its contents
+func exportJSON(t *testing.T, svc *Service) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	if err := svc.ExportToJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// seedArchiveData fills svc with every kind of record the archive holds
+func seedArchiveData(t *testing.T, svc *Service) {
+	t.Helper()
+
+	graphs, err := svc.CreateTag("graphs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"shortest paths", "unused"} {
+		tag, err := svc.CreateTag(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "shortest paths" {
+			if _, err := svc.SetTagParent(tag.ID, graphs.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	problems := []*models.Problem{
+		{Name: "Dijkstra", Link: "https://cses.fi/problemset/task/1671", Platform: "CSES", Difficulty: "Medium",
+			SolveTime: 40, Notes: "binary heap", CodeSnippet: "priority_queue<pair<ll,int>> pq;",
+			Tags: []models.Tag{{Name: "shortest paths"}, {Name: "graphs"}}},
+		{Name: "Frog 1", Platform: "AtCoder", Difficulty: "Easy"},
+	}
+	for _, p := range problems {
+		if err := svc.CreateProblem(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attempt := &models.Attempt{ProblemID: problems[0].ID, Duration: 25, Verdict: models.VerdictWrongAnswer,
+		Language: "C++", Code: "int main() {}", AttemptedAt: models.CustomTime{Time: time.Now().Add(-time.Hour)}}
+	if err := svc.AddAttempt(attempt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RecordReview(problems[0].ID, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := defaultBackupSettings
+	settings.KeepMonthly = 3
+	if err := svc.UpdateBackupSettings(&settings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	source := newTestService(t)
+	seedArchiveData(t, source)
+	first := exportJSON(t, source)
+
+	var archive models.Archive
+	if err := json.Unmarshal(first, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if archive.FormatVersion != models.ArchiveFormatVersion || len(archive.Tags) != 3 || len(archive.Problems) != 2 {
+		t.Fatalf("unexpected archive: %s", first)
+	}
+
+	path := filepath.Join(t.TempDir(), "archive.json")
+	if err := os.WriteFile(path, first, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := newTestService(t)
+	result, err := target.ImportFromJSON(path, nil)
+	if err != nil || result.Created != 2 {
+		t.Fatalf("import result = %+v, err %v", result, err)
+	}
+	if second := exportJSON(t, target); !bytes.Equal(first, second) {
+		t.Errorf("export after import differs:\n%s\nwant:\n%s", second, first)
+	}
+
+	// Importing again is a no-op
+	result, err = target.ImportFromJSON(path, nil)
+	if err != nil || result.Skipped != 2 {
+		t.Fatalf("repeated import result = %+v, err %v", result, err)
+	}
+	if third := exportJSON(t, target); !bytes.Equal(first, third) {
+		t.Errorf("export after repeated import differs:\n%s\nwant:\n%s", third, first)
+	}
+}
+
+func TestImportRejectsNewerArchive(t *testing.T) {
+	svc := newTestService(t)
+	path := filepath.Join(t.TempDir(), "archive.json")
+	data := []byte(`{"format_version": 99, "tags": [], "problems": []}`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ImportFromJSON(path, nil); err == nil {
+		t.Error("archive from a newer format version was accepted")
+	}
+}
+
+func TestImportVersion1Archive(t *testing.T) {
+	svc := newTestService(t)
+	path := filepath.Join(t.TempDir(), "archive.json")
+	data := []byte(`{"format_version": 1, "tags": [{"name": "dp", "created_at": "2023-01-01T00:00:00Z"}],
+		"problems": [{"name": "Frog 1", "link": "", "platform": "AtCoder", "difficulty": "Hard", "solve_time": 20,
+			"notes": "", "code_snippet": "", "tags": ["dp"],
+			"created_at": "2023-01-02T00:00:00Z", "updated_at": "2023-01-02T00:00:00Z"}]}`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.ImportFromJSON(path, nil)
+	if err != nil || result.Created != 1 {
+		t.Fatalf("import = %+v, err %v", result, err)
+	}
+	problems, err := svc.GetProblems(nil)
+	if err != nil || len(problems) != 1 {
+		t.Fatalf("got %d problems, err %v", len(problems), err)
+	}
+	if p := problems[0]; p.Status != models.StatusSolved || p.DifficultyScore != 2000 || len(p.Tags) != 1 {
+		t.Errorf("imported problem: status %q, score %d, tags %v", p.Status, p.DifficultyScore, p.Tags)
+	}
+}
+
+func TestImportArchiveValidatesRows(t *testing.T) {
+	svc := newTestService(t)
+	createProblems(t, svc, &models.Problem{Name: "Two Sum", Link: "https://leetcode.com/problems/two-sum/",
+		Difficulty: "Easy", Status: models.StatusMastered})
+
+	path := filepath.Join(t.TempDir(), "archive.json")
+	data := []byte(`{"format_version": 2, "tags": [], "problems": [
+		{"name": "Two Sum", "link": "https://leetcode.com/problems/two-sum", "platform": "lc", "difficulty": "Easy",
+			"status": "solved", "tags": [], "created_at": "2023-01-02T00:00:00Z", "updated_at": "2023-01-02T00:00:00Z"},
+		{"name": "Frog 1", "link": "", "platform": "atc", "difficulty": "Easy", "status": "done",
+			"tags": [], "created_at": "2023-01-02T00:00:00Z", "updated_at": "2023-01-02T00:00:00Z"},
+		{"name": "Frog 2", "link": "", "platform": "AtCoder", "difficulty": "Easy", "status": "todo",
+			"status_history": [{"from_status": "", "to_status": "queued", "changed_at": "2023-01-02T00:00:00Z"}],
+			"tags": [], "created_at": "2023-01-02T00:00:00Z", "updated_at": "2023-01-02T00:00:00Z"},
+		{"name": "Frog 3", "link": "", "platform": "atc", "difficulty": "Easy", "status": " Todo ",
+			"tags": [], "created_at": "2023-01-02T00:00:00Z", "updated_at": "2023-01-02T00:00:00Z"}]}`)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.ImportFromJSON(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Skipped != 1 || result.Created != 1 || result.Failed != 2 {
+		t.Fatalf("import = %+v, want 1 skipped, 1 created and 2 failed", result)
+	}
+	for i, row := range []int{2, 3} {
+		if rowErr := result.Errors[i]; rowErr.Row != row || len(rowErr.Fields) != 1 {
+			t.Errorf("error %d = %+v, want a field error on row %d", i, rowErr, row)
+		}
+	}
+
+	problems, err := svc.GetProblems(&models.ProblemFilter{Platform: "AtCoder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Name != "Frog 3" || problems[0].Status != models.StatusTodo {
+		t.Errorf("imported AtCoder problems = %+v, want Frog 3 in todo", problems)
+	}
+}
+
+func TestArchiveLeavesBackupPolicyBehind(t *testing.T) {
+	source := newTestService(t)
+	seedArchiveData(t, source)
+	if err := source.SetTimezone("Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+	data := exportJSON(t, source)
+
+	var archive models.Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := archive.Settings[backupSettingsKey]; ok || archive.Settings[timezoneSettingsKey] == "" {
+		t.Errorf("archived settings = %v, want the timezone only", archive.Settings)
+	}
+
+	// Archives written before the backup policy was left out still carry it
+	archive.Settings[backupSettingsKey] = `{"enabled":true,"directory":"/mnt/elsewhere","interval_hours":1}`
+	data, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "archive.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := newTestService(t)
+	if _, err := target.ImportFromJSON(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if zone, err := target.GetTimezone(); err != nil || zone != "Asia/Tokyo" {
+		t.Errorf("imported timezone = %q, %v", zone, err)
+	}
+	settings, err := target.GetBackupSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *settings != defaultBackupSettings {
+		t.Errorf("imported backup settings = %+v, want the defaults", settings)
+	}
+}
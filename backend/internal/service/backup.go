@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// snapshotSuffix names the copy of the database taken before a restore
+const snapshotSuffix = ".pre-restore"
+
+// backupTracker records the progress of the running or most recent backup
+// and allows only one backup at a time
+type backupTracker struct {
+	mu       sync.Mutex
+	progress models.BackupProgress
+}
+
+// start marks a backup to path as running, or returns false if one already is
+func (t *backupTracker) start(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.progress.Running {
+		return false
+	}
+	t.progress = models.BackupProgress{Running: true, Path: path}
+	return true
+}
+
+// update records the pages copied so far
+func (t *backupTracker) update(done, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.PagesDone = done
+	t.progress.PagesTotal = total
+	if total > 0 {
+		t.progress.Percent = float64(done) * 100 / float64(total)
+	}
+}
+
+// finish marks the backup as no longer running
+func (t *backupTracker) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.Running = false
+}
+
+// snapshot returns a copy of the current progress
+func (t *backupTracker) snapshot() models.BackupProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.progress
+}
+
+// BackupDatabase writes a consistent copy of the open database to backupPath
+// using the SQLite online backup API, so it is safe while other calls are
+// reading or writing. dbPath must name the open database. The copy passes
+// PRAGMA integrity_check before it replaces backupPath.
+func (s *Service) BackupDatabase(dbPath, backupPath string) error {
+	if err := s.checkOpenDatabase(dbPath); err != nil {
+		return err
+	}
+	if !s.backup.start(backupPath) {
+		return models.NewError(models.ErrCodeConflict, "a backup is already in progress")
+	}
+	defer s.backup.finish()
+
+	if err := database.Backup(s.db, backupPath, s.backup.update); err != nil {
+		return models.WrapError(models.ErrCodeIO, err, "failed to back up database: %v", err)
+	}
+	return nil
+}
+
+// GetBackupProgress returns the progress of the running or most recent backup
+func (s *Service) GetBackupProgress() models.BackupProgress {
+	return s.backup.snapshot()
+}
+
+// checkOpenDatabase verifies that dbPath is the file behind the service's
+// database handle
+func (s *Service) checkOpenDatabase(dbPath string) error {
+	file, err := s.databaseFile()
+	if err != nil {
+		return err
+	}
+
+	requested, err := os.Stat(dbPath)
+	if err != nil {
+		return models.WrapError(models.ErrCodeIO, err, "cannot access database %s: %v", dbPath, err)
+	}
+	open, err := os.Stat(file)
+	if err != nil || !os.SameFile(requested, open) {
+		return models.NewError(models.ErrCodeValidation, "%s is not the open database", dbPath)
+	}
+	return nil
+}
+
+// databaseFile returns the path of the file behind the database handle
+func (s *Service) databaseFile() (string, error) {
+	var seq int
+	var name, file string
+	if err := s.db.QueryRow("PRAGMA database_list").Scan(&seq, &name, &file); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// RestoreDatabase replaces the open database at dbPath with a validated
+// backup, keeping a snapshot of the current data next to it. The service is
+// closed and the returned service takes its place; on failure it is the
+// service to keep using, or nil if the database could not be reopened.
+func (s *Service) RestoreDatabase(dbPath, backupPath string) (*Service, *models.RestoreResult, error) {
+	if err := s.checkOpenDatabase(dbPath); err != nil {
+		return s, nil, err
+	}
+	snapshotPath := dbPath + snapshotSuffix
+	db, err := database.Restore(s.db, dbPath, backupPath, snapshotPath)
+
+	next := s
+	if db != s.db {
+		next = nil
+		if db != nil {
+			next = NewService(db)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidBackup) {
+			return next, nil, models.WrapError(models.ErrCodeValidation, err, "%v", err)
+		}
+		return next, nil, err
+	}
+	return next, &models.RestoreResult{SnapshotPath: snapshotPath}, nil
+}
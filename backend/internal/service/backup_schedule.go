@@ -0,0 +1,344 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+const (
+	// backupSettingsKey is the settings row holding the backup policy
+	backupSettingsKey = "backup"
+
+	// defaultBackupDir is the backup directory, relative to the database
+	// directory, used when none is configured
+	defaultBackupDir = "backups"
+
+	// Scheduled backups are named algorithm_tracker-<UTC timestamp>.db. The
+	// timestamp has one-second resolution, so a backup taken within the same
+	// second as another gets a -<n> suffix after it.
+	backupFilePrefix = "algorithm_tracker-"
+	backupFileSuffix = ".db"
+	backupTimeLayout = "20060102T150405Z"
+)
+
+// defaultBackupSettings is the policy used until the user changes it: a daily
+// backup into the backups folder next to the database, keeping a week of
+// daily, a month of weekly and a year of monthly backups
+var defaultBackupSettings = models.BackupSettings{
+	Enabled:       true,
+	IntervalHours: 24,
+	KeepDaily:     7,
+	KeepWeekly:    4,
+	KeepMonthly:   12,
+}
+
+// GetBackupSettings returns the stored backup policy, or the defaults
+func (s *Service) GetBackupSettings() (*models.BackupSettings, error) {
+	settings := defaultBackupSettings
+
+	value, ok, err := s.repo.GetSetting(backupSettingsKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := json.Unmarshal([]byte(value), &settings); err != nil {
+			return nil, fmt.Errorf("invalid stored backup settings: %w", err)
+		}
+	}
+	return &settings, nil
+}
+
+// UpdateBackupSettings validates and stores the backup policy
+func (s *Service) UpdateBackupSettings(settings *models.BackupSettings) error {
+	if err := validateBackupSettings(settings); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetSetting(backupSettingsKey, string(value))
+}
+
+// RunScheduledBackup takes a backup into the backup directory if automatic
+// backups are enabled and the newest one is older than the configured
+// interval, then prunes old backups. It returns the new backup, or nil if
+// none was due.
+func (s *Service) RunScheduledBackup() (*models.BackupInfo, error) {
+	return s.runScheduledBackup(time.Now())
+}
+
+func (s *Service) runScheduledBackup(now time.Time) (*models.BackupInfo, error) {
+	settings, err := s.GetBackupSettings()
+	if err != nil || !settings.Enabled {
+		return nil, err
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	interval := time.Duration(settings.IntervalHours) * time.Hour
+	if len(backups) > 0 && now.Sub(backups[0].CreatedAt.Time) < interval {
+		return nil, nil
+	}
+
+	dir, err := s.backupDir(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, models.WrapError(models.ErrCodeIO, err, "cannot create backup directory: %v", err)
+	}
+
+	dbFile, err := s.databaseFile()
+	if err != nil {
+		return nil, err
+	}
+	name, err := backupFileName(dir, now)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name)
+	if err := s.BackupDatabase(dbFile, path); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.PruneBackups(); err != nil {
+		return nil, err
+	}
+
+	info, err := backupInfo(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListBackups returns the scheduled backups in the backup directory, newest
+// first. Other files in the directory are ignored.
+func (s *Service) ListBackups() ([]models.BackupInfo, error) {
+	settings, err := s.GetBackupSettings()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.backupDir(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, models.WrapError(models.ErrCodeIO, err, "cannot read backup directory: %v", err)
+	}
+
+	backups := []models.BackupInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := backupInfo(dir, entry.Name())
+		if err != nil || info == nil {
+			continue
+		}
+		backups = append(backups, *info)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		a, b := backups[i].CreatedAt.Time, backups[j].CreatedAt.Time
+		if a.Equal(b) {
+			_, seqA, _ := parseBackupName(backups[i].Name)
+			_, seqB, _ := parseBackupName(backups[j].Name)
+			return seqA > seqB
+		}
+		return a.After(b)
+	})
+	return backups, nil
+}
+
+// PruneBackups deletes the backups the retention policy does not keep and
+// returns them
+func (s *Service) PruneBackups() ([]models.BackupInfo, error) {
+	settings, err := s.GetBackupSettings()
+	if err != nil {
+		return nil, err
+	}
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := retainedBackups(backups, settings)
+	removed := []models.BackupInfo{}
+	for _, backup := range backups {
+		if keep[backup.Path] {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return removed, models.WrapError(models.ErrCodeIO, err, "cannot remove backup %s: %v", backup.Name, err)
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+// retainedBackups returns the paths of the backups to keep: the newest one in
+// each of the most recent KeepDaily days, KeepWeekly ISO weeks and
+// KeepMonthly months. backups must be sorted newest first.
+func retainedBackups(backups []models.BackupInfo, settings *models.BackupSettings) map[string]bool {
+	keep := make(map[string]bool)
+
+	retain := func(count int, period func(t time.Time) string) {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			if len(seen) >= count {
+				return
+			}
+			key := period(backup.CreatedAt.Time.Local())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[backup.Path] = true
+		}
+	}
+
+	retain(settings.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	retain(settings.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	retain(settings.KeepMonthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	return keep
+}
+
+// backupFileName returns the name for a backup taken at now, numbered after
+// any backup in dir taken within the same second
+func backupFileName(dir string, now time.Time) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", models.WrapError(models.ErrCodeIO, err, "cannot read backup directory: %v", err)
+	}
+
+	stamp := now.UTC().Format(backupTimeLayout)
+	taken, _ := time.Parse(backupTimeLayout, stamp)
+	last := 0
+	for _, entry := range entries {
+		if createdAt, seq, ok := parseBackupName(entry.Name()); ok && createdAt.Equal(taken) {
+			last = max(last, seq)
+		}
+	}
+	if last == 0 {
+		return backupFilePrefix + stamp + backupFileSuffix, nil
+	}
+	return fmt.Sprintf("%s%s-%d%s", backupFilePrefix, stamp, last+1, backupFileSuffix), nil
+}
+
+// backupInfo describes the file name in dir, or returns nil if it is not a
+// scheduled backup
+func backupInfo(dir, name string) (*models.BackupInfo, error) {
+	createdAt, _, ok := parseBackupName(name)
+	if !ok {
+		return nil, nil
+	}
+
+	path := filepath.Join(dir, name)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BackupInfo{
+		Name:      name,
+		Path:      path,
+		Size:      stat.Size(),
+		CreatedAt: models.CustomTime{Time: createdAt},
+	}, nil
+}
+
+// parseBackupName returns the time a scheduled backup was taken and its
+// sequence number within that second, starting at 1, or false if name is not
+// a scheduled backup
+func parseBackupName(name string) (time.Time, int, bool) {
+	if !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, backupFileSuffix) {
+		return time.Time{}, 0, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileSuffix)
+	seq := 1
+	if base, n, ok := strings.Cut(stamp, "-"); ok {
+		var err error
+		if seq, err = strconv.Atoi(n); err != nil || seq < 2 {
+			return time.Time{}, 0, false
+		}
+		stamp = base
+	}
+	createdAt, err := time.Parse(backupTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return createdAt, seq, true
+}
+
+// backupDir resolves the configured backup directory; relative paths are
+// taken relative to the database directory
+func (s *Service) backupDir(settings *models.BackupSettings) (string, error) {
+	dir := settings.Directory
+	if dir == "" {
+		dir = defaultBackupDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	dbFile, err := s.databaseFile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(dbFile), dir), nil
+}
+
+// validateBackupSettings validates a backup policy
+func validateBackupSettings(settings *models.BackupSettings) error {
+	var fields []models.FieldError
+
+	if settings.IntervalHours < 0 {
+		fields = append(fields, models.FieldError{Field: "interval_hours", Message: "interval_hours cannot be negative"})
+	}
+	keeps := []struct {
+		field string
+		value int
+	}{
+		{"keep_daily", settings.KeepDaily},
+		{"keep_weekly", settings.KeepWeekly},
+		{"keep_monthly", settings.KeepMonthly},
+	}
+	for _, keep := range keeps {
+		if keep.value < 0 {
+			fields = append(fields, models.FieldError{Field: keep.field, Message: keep.field + " cannot be negative"})
+		}
+	}
+	if settings.KeepDaily <= 0 && settings.KeepWeekly <= 0 && settings.KeepMonthly <= 0 {
+		fields = append(fields, models.FieldError{Field: "keep_daily", Message: "retention must keep at least one backup"})
+	}
+
+	if len(fields) > 0 {
+		return models.NewValidationError(fields)
+	}
+	return nil
+}
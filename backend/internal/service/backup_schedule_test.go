@@ -0,0 +1,177 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// newTestService creates a service backed by a fresh database
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(db)
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func TestRetainedBackups(t *testing.T) {
+	// One backup every 12 hours for 60 days, newest first
+	start := time.Date(2024, 3, 31, 12, 0, 0, 0, time.Local)
+	var backups []models.BackupInfo
+	for i := 0; i < 120; i++ {
+		at := start.Add(-time.Duration(i) * 12 * time.Hour)
+		backups = append(backups, models.BackupInfo{
+			Path:      at.Format("2006-01-02T15"),
+			CreatedAt: models.CustomTime{Time: at},
+		})
+	}
+
+	settings := &models.BackupSettings{KeepDaily: 3, KeepWeekly: 2, KeepMonthly: 2}
+	var kept []string
+	for path := range retainedBackups(backups, settings) {
+		kept = append(kept, path)
+	}
+	sort.Strings(kept)
+
+	want := []string{
+		"2024-02-29T12", // newest of February
+		"2024-03-24T12", // newest of the week ending Sunday the 24th
+		"2024-03-29T12",
+		"2024-03-30T12",
+		"2024-03-31T12", // newest daily, weekly and monthly
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Fatalf("kept %v, want %v", kept, want)
+		}
+	}
+}
+
+func TestRunScheduledBackup(t *testing.T) {
+	svc := newTestService(t)
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)
+	info, err := svc.runScheduledBackup(now.Add(-30 * 24 * time.Hour))
+	if err != nil || info == nil {
+		t.Fatalf("default settings took backup %v, err %v; want one", info, err)
+	}
+	dbFile, err := svc.databaseFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(dbFile), defaultBackupDir); filepath.Dir(info.Path) != want {
+		t.Errorf("default backup in %s, want %s", filepath.Dir(info.Path), want)
+	}
+
+	settings := defaultBackupSettings
+	settings.IntervalHours = 6
+	settings.KeepDaily = 1
+	settings.KeepWeekly = 0
+	settings.KeepMonthly = 0
+	if err := svc.UpdateBackupSettings(&settings); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		at    time.Time
+		taken bool
+		count int
+	}{
+		{now, true, 1},
+		{now.Add(time.Hour), false, 1},
+		{now.Add(7 * time.Hour), true, 1},     // same day, so the earlier one is pruned
+		{now.Add(24 * time.Hour), true, 1},    // keep_daily is 1
+		{now.Add(24*time.Hour + 1), false, 1}, // within the interval
+	}
+	for i, step := range steps {
+		info, err := svc.runScheduledBackup(step.at)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if (info != nil) != step.taken {
+			t.Errorf("step %d: backup taken = %v, want %v", i, info != nil, step.taken)
+		}
+		backups, err := svc.ListBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) != step.count {
+			t.Errorf("step %d: %d backups, want %d", i, len(backups), step.count)
+		}
+	}
+
+	settings.Enabled = false
+	if err := svc.UpdateBackupSettings(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := svc.runScheduledBackup(now.Add(48 * time.Hour)); err != nil || info != nil {
+		t.Errorf("disabled schedule took backup %v, err %v", info, err)
+	}
+}
+
+func TestBackupsWithinOneSecond(t *testing.T) {
+	svc := newTestService(t)
+	settings := defaultBackupSettings
+	settings.Enabled = true
+	settings.IntervalHours = 0
+	if err := svc.UpdateBackupSettings(&settings); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 3; i++ {
+		info, err := svc.runScheduledBackup(now.Add(time.Duration(i) * time.Millisecond))
+		if err != nil || info == nil {
+			t.Fatalf("backup %d = %v, err %v", i, info, err)
+		}
+		names = append(names, info.Name)
+	}
+	want := []string{
+		"algorithm_tracker-20240501T080000Z.db",
+		"algorithm_tracker-20240501T080000Z-2.db",
+		"algorithm_tracker-20240501T080000Z-3.db",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	// All three fall on one day, so only the last one taken is kept
+	backups, err := svc.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Name != want[2] || !backups[0].CreatedAt.Equal(now) {
+		t.Errorf("backups = %+v, want only %s", backups, want[2])
+	}
+}
+
+func TestUpdateBackupSettingsValidation(t *testing.T) {
+	svc := newTestService(t)
+
+	settings := models.BackupSettings{Enabled: true, IntervalHours: -1}
+	err := svc.UpdateBackupSettings(&settings)
+	if err == nil {
+		t.Fatal("invalid settings were accepted")
+	}
+
+	stored, err := svc.GetBackupSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stored != defaultBackupSettings {
+		t.Errorf("stored settings = %+v, want defaults", *stored)
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/platform"
+)
+
+// difficultyLevels are the levels every difficulty scale maps onto
+var difficultyLevels = []string{models.DifficultyEasy, models.DifficultyMedium, models.DifficultyHard}
+
+// difficultyLevel returns the known difficulty level matching value in any
+// case, or "" if value is not a level
+func difficultyLevel(value string) string {
+	for _, level := range difficultyLevels {
+		if strings.EqualFold(strings.TrimSpace(value), level) {
+			return level
+		}
+	}
+	return ""
+}
+
+// difficultyLabelKey folds a difficulty label for comparison. Luogu's labels
+// are often copied with a minus sign instead of a hyphen.
+func difficultyLabelKey(value string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(value), "−", "-"))
+}
+
+// parseDifficultyNumber parses a numeric difficulty such as a rating
+func parseDifficultyNumber(value string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+// difficultyScales resolves difficulties against the stored scales, loading
+// each platform's scale at most once
+type difficultyScales struct {
+	s      *Service
+	scales map[string][]models.DifficultyPoint
+}
+
+func (s *Service) newDifficultyScales() *difficultyScales {
+	return &difficultyScales{s: s, scales: make(map[string][]models.DifficultyPoint)}
+}
+
+func (d *difficultyScales) scale(name string) ([]models.DifficultyPoint, error) {
+	if points, ok := d.scales[name]; ok {
+		return points, nil
+	}
+	points, err := d.s.repo.GetDifficultyScale(name)
+	if err != nil {
+		return nil, err
+	}
+	d.scales[name] = points
+	return points, nil
+}
+
+// resolve looks value up on the platform's scale and then on the fallback
+// scale. Labels match in any case; a number between two numeric points of a
+// scale is interpolated, and one beyond its ends is clamped to them.
+func (d *difficultyScales) resolve(platformName, value string) (models.DifficultyPoint, bool, error) {
+	names := []string{platformName}
+	if platformName != "" {
+		names = append(names, "")
+	}
+	for _, name := range names {
+		points, err := d.scale(name)
+		if err != nil {
+			return models.DifficultyPoint{}, false, err
+		}
+		if p, ok := resolveDifficulty(points, value); ok {
+			return p, true, nil
+		}
+	}
+	return models.DifficultyPoint{}, false, nil
+}
+
+// resolveDifficulty resolves value on a single scale. A match returns the
+// point with the matched label as Value, or with value itself for numbers.
+func resolveDifficulty(points []models.DifficultyPoint, value string) (models.DifficultyPoint, bool) {
+	key := difficultyLabelKey(value)
+	for _, p := range points {
+		if difficultyLabelKey(p.Value) == key {
+			return p, true
+		}
+	}
+
+	x, ok := parseDifficultyNumber(value)
+	if !ok {
+		return models.DifficultyPoint{}, false
+	}
+	type numericPoint struct {
+		n float64
+		models.DifficultyPoint
+	}
+	var numeric []numericPoint
+	for _, p := range points {
+		if n, ok := parseDifficultyNumber(p.Value); ok {
+			numeric = append(numeric, numericPoint{n, p})
+		}
+	}
+	if len(numeric) == 0 {
+		return models.DifficultyPoint{}, false
+	}
+	sort.Slice(numeric, func(i, j int) bool { return numeric[i].n < numeric[j].n })
+
+	result := models.DifficultyPoint{Value: strings.TrimSpace(value)}
+	switch last := numeric[len(numeric)-1]; {
+	case x <= numeric[0].n:
+		result.Score, result.Level = numeric[0].Score, numeric[0].Level
+	case x >= last.n:
+		result.Score, result.Level = last.Score, last.Level
+	default:
+		i := sort.Search(len(numeric), func(i int) bool { return numeric[i].n > x }) - 1
+		lo, hi := numeric[i], numeric[i+1]
+		result.Score = lo.Score + int(math.Round((x-lo.n)/(hi.n-lo.n)*float64(hi.Score-lo.Score)))
+		result.Level = lo.Level
+	}
+	return result, true
+}
+
+// score fills in problem's difficulty fields from its raw difficulty and
+// level. A Difficulty that is not a level is taken as the raw difficulty.
+// The raw difficulty sets the score, and the level when none is given, so a
+// level passed alongside it overrides the scale's. Without a resolvable raw
+// difficulty the score comes from the level. It returns a field error if
+// neither is usable.
+func (d *difficultyScales) score(problem *models.Problem) (*models.FieldError, error) {
+	problem.RawDifficulty = strings.TrimSpace(problem.RawDifficulty)
+	problem.Difficulty = strings.TrimSpace(problem.Difficulty)
+	if level := difficultyLevel(problem.Difficulty); level != "" {
+		problem.Difficulty = level
+	} else if problem.RawDifficulty == "" {
+		problem.RawDifficulty, problem.Difficulty = problem.Difficulty, ""
+	}
+
+	if problem.RawDifficulty == "" && problem.Difficulty == "" {
+		return &models.FieldError{Field: "difficulty", Message: "difficulty is required"}, nil
+	}
+
+	if problem.RawDifficulty != "" {
+		point, ok, err := d.resolve(problem.Platform, problem.RawDifficulty)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			problem.RawDifficulty = point.Value
+			problem.DifficultyScore = point.Score
+			if problem.Difficulty == "" {
+				problem.Difficulty = point.Level
+			}
+			return nil, nil
+		}
+		if problem.Difficulty == "" {
+			return &models.FieldError{Field: "difficulty", Message: fmt.Sprintf(
+				"difficulty %q is not on the %s scale; give a level of Easy, Medium or Hard", problem.RawDifficulty, scaleName(problem.Platform))}, nil
+		}
+	}
+
+	point, _, err := d.resolve(problem.Platform, problem.Difficulty)
+	if err != nil {
+		return nil, err
+	}
+	problem.DifficultyScore = point.Score
+	return nil, nil
+}
+
+// scaleName names a platform's difficulty scale in messages
+func scaleName(platformName string) string {
+	if platformName == "" {
+		return "fallback"
+	}
+	return platformName
+}
+
+// GetDifficultyScales returns every difficulty scale, the fallback scale
+// first
+func (s *Service) GetDifficultyScales() ([]models.DifficultyScale, error) {
+	return s.repo.GetDifficultyScales()
+}
+
+// UpdateDifficultyScale replaces a platform's difficulty scale, or the
+// fallback scale if the platform is empty, and rescores the problems it
+// applies to. An empty scale removes the platform's own scale; the fallback
+// scale must keep a point for each level, since it scores problems that only
+// have a level.
+func (s *Service) UpdateDifficultyScale(scale *models.DifficultyScale) error {
+	scale.Platform = platform.Canonical(scale.Platform)
+
+	var fields []models.FieldError
+	seen := make(map[string]bool)
+	for i := range scale.Points {
+		p := &scale.Points[i]
+		p.Value = strings.TrimSpace(p.Value)
+		field := fmt.Sprintf("points[%d]", i)
+		if p.Value == "" {
+			fields = append(fields, models.FieldError{Field: field + ".value", Message: "value is required"})
+		} else if key := difficultyLabelKey(p.Value); seen[key] {
+			fields = append(fields, models.FieldError{Field: field + ".value", Message: fmt.Sprintf("value %q is listed twice", p.Value)})
+		} else {
+			seen[key] = true
+		}
+		if p.Score <= 0 {
+			fields = append(fields, models.FieldError{Field: field + ".score", Message: "score must be positive"})
+		}
+		if level := difficultyLevel(p.Level); level != "" {
+			p.Level = level
+		} else {
+			fields = append(fields, models.FieldError{Field: field + ".level", Message: "level must be Easy, Medium, or Hard"})
+		}
+	}
+	if scale.Platform == "" {
+		for _, level := range difficultyLevels {
+			if !seen[difficultyLabelKey(level)] {
+				fields = append(fields, models.FieldError{Field: "points", Message: fmt.Sprintf("the fallback scale must define %s", level)})
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return models.NewValidationError(fields)
+	}
+
+	if err := s.repo.SaveDifficultyScale(scale); err != nil {
+		return err
+	}
+	return s.rescoreDifficulties(scale.Platform)
+}
+
+// rescoreDifficulties recomputes the difficulty score of the problems on
+// platform, or of all problems if platform is empty. A problem whose raw
+// difficulty no longer resolves keeps its level and is scored by it.
+func (s *Service) rescoreDifficulties(platformName string) error {
+	problems, err := s.repo.GetProblemDifficulties(platformName)
+	if err != nil {
+		return err
+	}
+
+	scales := s.newDifficultyScales()
+	var changed []models.Problem
+	for _, p := range problems {
+		before := p.DifficultyScore
+		if _, err := scales.score(&p); err != nil {
+			return err
+		}
+		if p.DifficultyScore != before {
+			changed = append(changed, p)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return s.repo.SetDifficultyScores(changed)
+}
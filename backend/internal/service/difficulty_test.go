@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestDifficultyScales(t *testing.T) {
+	svc := newTestService(t)
+	cf := &models.Problem{Name: "Rated", Platform: "Codeforces", Difficulty: "1500"}
+	at := &models.Problem{Name: "Points", Platform: "AtCoder", Difficulty: "450"}
+	luogu := &models.Problem{Name: "Label", Platform: "Luogu", RawDifficulty: "普及−"}
+	lc := &models.Problem{Name: "Level", Platform: "LeetCode", Difficulty: "hard"}
+	override := &models.Problem{Name: "Override", Platform: "Codeforces", Difficulty: "Hard", RawDifficulty: "1200"}
+	createProblems(t, svc, cf, at, luogu, lc, override)
+
+	tests := []struct {
+		problem *models.Problem
+		raw     string
+		level   string
+		score   int
+	}{
+		{cf, "1500", "Medium", 1500},
+		{at, "450", "Medium", 1550},
+		{luogu, "普及-", "Easy", 1000},
+		{lc, "", "Hard", 2000},
+		{override, "1200", "Hard", 1200},
+	}
+	for _, tt := range tests {
+		p := tt.problem
+		if p.RawDifficulty != tt.raw || p.Difficulty != tt.level || p.DifficultyScore != tt.score {
+			t.Errorf("%s: raw %q, level %q, score %d; want %q, %q, %d",
+				p.Name, p.RawDifficulty, p.Difficulty, p.DifficultyScore, tt.raw, tt.level, tt.score)
+		}
+	}
+
+	var appErr *models.Error
+	err := svc.CreateProblem(&models.Problem{Name: "Unrated", Platform: "LeetCode", Difficulty: "1500"})
+	if !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("rating on a platform without a numeric scale: err = %v, want VALIDATION", err)
+	}
+
+	problems, err := svc.GetProblems(&models.ProblemFilter{MinScore: 1400, MaxScore: 1600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 {
+		t.Errorf("problems scored 1400-1600 = %d, want 2", len(problems))
+	}
+
+	stats, err := svc.GetStatistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ScoreBucket{
+		{Min: 1000, Max: 1200, Count: 1},
+		{Min: 1200, Max: 1400, Count: 1},
+		{Min: 1400, Max: 1600, Count: 2},
+		{Min: 1600, Max: 1800},
+		{Min: 1800, Max: 2000},
+		{Min: 2000, Max: 2200, Count: 1},
+	}
+	if len(stats.ByScoreRange) != len(want) {
+		t.Fatalf("score ranges = %+v, want %+v", stats.ByScoreRange, want)
+	}
+	for i := range want {
+		if stats.ByScoreRange[i] != want[i] {
+			t.Errorf("score range %d = %+v, want %+v", i, stats.ByScoreRange[i], want[i])
+		}
+	}
+}
+
+func TestUpdateDifficultyScaleRescores(t *testing.T) {
+	svc := newTestService(t)
+	cf := &models.Problem{Name: "Rated", Platform: "Codeforces", Difficulty: "1500"}
+	lc := &models.Problem{Name: "Level", Platform: "LeetCode", Difficulty: "Medium"}
+	createProblems(t, svc, cf, lc)
+
+	scale := &models.DifficultyScale{Platform: "cf", Points: []models.DifficultyPoint{
+		{Value: "1000", Score: 1000, Level: "easy"},
+		{Value: "2000", Score: 3000, Level: "Hard"},
+	}}
+	if err := svc.UpdateDifficultyScale(scale); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := svc.GetProblem(cf.ID); err != nil || got.DifficultyScore != 2000 {
+		t.Errorf("rescored problem = %+v, %v; want score 2000", got, err)
+	}
+
+	fallback := &models.DifficultyScale{Points: []models.DifficultyPoint{
+		{Value: "Easy", Score: 1000, Level: "Easy"},
+		{Value: "Medium", Score: 1500, Level: "Medium"},
+		{Value: "Hard", Score: 2500, Level: "Hard"},
+	}}
+	if err := svc.UpdateDifficultyScale(fallback); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := svc.GetProblem(lc.ID); err != nil || got.DifficultyScore != 1500 {
+		t.Errorf("rescored level-only problem = %+v, %v; want score 1500", got, err)
+	}
+
+	invalid := &models.DifficultyScale{Points: []models.DifficultyPoint{
+		{Value: "Easy", Score: 1000, Level: "Easy"},
+		{Value: "easy", Score: 0, Level: "Trivial"},
+	}}
+	var appErr *models.Error
+	if err := svc.UpdateDifficultyScale(invalid); !errors.As(err, &appErr) || len(appErr.Fields) != 5 {
+		t.Errorf("invalid scale: err = %v, want 5 field errors", err)
+	}
+}
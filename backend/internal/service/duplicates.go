@@ -0,0 +1,436 @@
+package service
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+const (
+	// similarNameThreshold is the name similarity from which two problems on
+	// the same platform are reported as possible duplicates
+	similarNameThreshold = 0.8
+
+	// maxSimilarProblems caps the "did you mean" suggestions for one problem
+	maxSimilarProblems = 5
+)
+
+// checkLinkDuplicate returns a CONFLICT error if another problem has the same
+// link or platform problem ID as problem
+func (s *Service) checkLinkDuplicate(problem *models.Problem) error {
+	id, err := s.repo.FindLinkDuplicate(problem)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	existing, err := s.repo.GetProblem(id)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("problem already exists as #%d %q", existing.ID, existing.Name)
+	return &models.Error{
+		Code:    models.ErrCodeConflict,
+		Message: message,
+		Fields:  []models.FieldError{{Field: "link", Message: message}},
+	}
+}
+
+// SimilarProblems returns problems on the same platform whose name is similar
+// to problem's, most similar first. It backs the "did you mean" warning when
+// a problem is saved, so problem itself is never included, and neither are
+// problems whose link names a different platform problem.
+func (s *Service) SimilarProblems(problem *models.Problem) ([]models.DuplicateMatch, error) {
+	keys, err := s.repo.GetProblemKeys(problem.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	name := newFoldedName(problem.Name)
+	similarity := make(map[int]float64)
+	var ids []int
+	for _, k := range keys {
+		if k.ID == problem.ID || distinctCanonicalIDs(k.CanonicalID, problem.CanonicalID) {
+			continue
+		}
+		if sim, ok := nameSimilarity(name, newFoldedName(k.Name)); ok {
+			similarity[k.ID] = sim
+			ids = append(ids, k.ID)
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return similarity[ids[i]] > similarity[ids[j]] })
+	if len(ids) > maxSimilarProblems {
+		ids = ids[:maxSimilarProblems]
+	}
+
+	problems, err := s.repo.GetProblemsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]models.DuplicateMatch, len(problems))
+	for i, p := range problems {
+		matches[i] = models.DuplicateMatch{Problem: p, Reason: models.DuplicateSimilarName, Similarity: similarity[p.ID]}
+	}
+	return matches, nil
+}
+
+// FindDuplicates scans every problem and returns clusters of likely
+// duplicates: problems sharing a link or platform problem ID, and problems
+// on the same platform with similar names unless their links name different
+// problems. Clusters are ordered by their
+// lowest problem ID and list their problems by ID.
+func (s *Service) FindDuplicates() ([]models.DuplicateCluster, error) {
+	keys, err := s.repo.GetProblemKeys("")
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := newDisjointSet(len(keys))
+	linked := make(map[int]bool) // indexes of problems sharing a link with another
+
+	first := make(map[string]int)
+	join := func(key string, i int) {
+		if j, ok := first[key]; ok {
+			clusters.union(i, j)
+			linked[i], linked[j] = true, true
+		} else {
+			first[key] = i
+		}
+	}
+	byPlatform := make(map[string][]int)
+	names := make([]*foldedName, len(keys))
+	for i, k := range keys {
+		if k.Link != "" {
+			join("link\x00"+k.Link, i)
+		}
+		if k.CanonicalID != "" {
+			join("id\x00"+k.Platform+"\x00"+k.CanonicalID, i)
+		}
+		names[i] = newFoldedName(k.Name)
+		byPlatform[k.Platform] = append(byPlatform[k.Platform], i)
+	}
+
+	// Compare the names within each platform, skipping pairs already in one
+	// cluster and pairs whose links identify different problems, such as
+	// Combination Sum II and III
+	for _, members := range byPlatform {
+		similarNameCandidates(names, members, func(i, j int) {
+			if clusters.find(i) == clusters.find(j) || distinctCanonicalIDs(keys[i].CanonicalID, keys[j].CanonicalID) {
+				return
+			}
+			if _, ok := nameSimilarity(names[i], names[j]); ok {
+				clusters.union(i, j)
+			}
+		})
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range keys {
+		root := clusters.find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	var result []models.DuplicateCluster
+	for _, root := range roots {
+		members := groups[root]
+		if len(members) < 2 {
+			continue
+		}
+		cluster := models.DuplicateCluster{Reason: models.DuplicateSimilarName}
+		ids := make([]int, len(members))
+		for n, i := range members {
+			ids[n] = keys[i].ID
+			if linked[i] {
+				cluster.Reason = models.DuplicateLink
+			}
+		}
+		if cluster.Problems, err = s.repo.GetProblemsByIDs(ids); err != nil {
+			return nil, err
+		}
+		result = append(result, cluster)
+	}
+	return result, nil
+}
+
+// MergeProblems merges the problems dropIDs into keepID, uniting their tags,
+// notes and attempt history, deletes them and returns the merged problem
+func (s *Service) MergeProblems(keepID int, dropIDs []int) (*models.Problem, error) {
+	if len(dropIDs) == 0 {
+		return nil, models.NewValidationError([]models.FieldError{
+			{Field: "drop_ids", Message: "at least one problem to merge is required"},
+		})
+	}
+	if err := s.repo.MergeProblems(keepID, dropIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetProblem(keepID)
+}
+
+// distinctCanonicalIDs reports whether two problems on the same platform are
+// known to be different problems: both links were parsed to a platform
+// problem ID and the IDs differ
+func distinctCanonicalIDs(a, b string) bool {
+	return a != "" && b != "" && a != b
+}
+
+// duplicateNameKey folds a problem name for comparison: lower case, words
+// separated by single spaces, punctuation dropped and a leading problem
+// number such as "1." in "1. Two Sum" removed
+func duplicateNameKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > 1 && strings.IndexFunc(words[0], func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// foldedName is a problem name folded by duplicateNameKey and prepared for
+// comparison with other names
+type foldedName struct {
+	text    string
+	runes   []rune
+	offsets []int // byte offset in text of each rune and of the end
+	// classes has bit c set when counts[c] > 0, and counts holds the number
+	// of runes in each class, see nameClass
+	classes uint64
+	counts  [nameClasses]int16
+}
+
+// nameClasses is the number of rune classes counted in a foldedName: letters
+// a-z, digits, space, and the remaining runes hashed into 27 classes
+const nameClasses = 64
+
+func newFoldedName(name string) *foldedName {
+	f := &foldedName{text: duplicateNameKey(name)}
+	for offset, r := range f.text {
+		f.runes = append(f.runes, r)
+		f.offsets = append(f.offsets, offset)
+		c := nameClass(r)
+		f.classes |= 1 << c
+		f.counts[c]++
+	}
+	f.offsets = append(f.offsets, len(f.text))
+	return f
+}
+
+// slice returns the runes from start up to end as a string
+func (f *foldedName) slice(start, end int) string {
+	return f.text[f.offsets[start]:f.offsets[end]]
+}
+
+// nameClass maps a rune of a folded name to its class
+func nameClass(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return int(r - 'a')
+	case r >= '0' && r <= '9':
+		return 26 + int(r-'0')
+	case r == ' ':
+		return 36
+	}
+	return 37 + int(r%27)
+}
+
+// nameSegment keys the index of similarNameCandidates: the text of one of
+// the segments a name of the given length is split into
+type nameSegment struct {
+	length, segment int
+	text            string
+}
+
+// similarNameCandidates calls fn once with every pair of the members whose
+// names may reach similarNameThreshold, which includes every pair that does.
+// Rather than listing every pair, each name is split into one more segment
+// than the edit distance it allows, so a similar name must contain one of its
+// segments, shifted by at most that distance. Names are indexed by their
+// segments from the longest down, and each name is only paired with the
+// longer or equally long names that share a segment.
+func similarNameCandidates(names []*foldedName, members []int, fn func(i, j int)) {
+	sorted := slices.Clone(members)
+	sort.SliceStable(sorted, func(a, b int) bool { return len(names[sorted[a]].runes) > len(names[sorted[b]].runes) })
+
+	index := make(map[nameSegment][]int)
+	paired := make(map[int]int) // member to the last name paired with it
+	for _, i := range sorted {
+		name := names[i]
+		n := len(name.runes)
+		for length := n; length-n <= maxNameDistance(length); length++ {
+			distance := maxNameDistance(length)
+			for segment := 0; segment <= distance; segment++ {
+				start, end := segmentBounds(length, distance, segment)
+				width := end - start
+				for pos := max(0, start-distance); pos <= min(n-width, start+distance); pos++ {
+					// The edits before the segment shift it by pos-start and
+					// those after it by the rest of the length difference.
+					// When this is the first segment without edits, each
+					// segment before it holds at least one edit.
+					shift, rest := abs(pos-start), abs(n-length-(pos-start))
+					if shift+rest > distance || rest > distance-segment {
+						continue
+					}
+					key := nameSegment{length, segment, name.slice(pos, pos+width)}
+					for _, j := range index[key] {
+						if last, ok := paired[j]; ok && last == i {
+							continue
+						}
+						paired[j] = i
+						fn(j, i)
+					}
+				}
+			}
+		}
+
+		distance := maxNameDistance(n)
+		for segment := 0; segment <= distance; segment++ {
+			start, end := segmentBounds(n, distance, segment)
+			key := nameSegment{n, segment, name.slice(start, end)}
+			index[key] = append(index[key], i)
+		}
+	}
+}
+
+// segmentBounds returns where segment number segment starts and ends when a
+// name of length length is split into distance+1 segments of near equal
+// length
+func segmentBounds(length, distance, segment int) (start, end int) {
+	return segment * length / (distance + 1), (segment + 1) * length / (distance + 1)
+}
+
+// maxNameDistance returns the largest edit distance from a name of length
+// length, or from a shorter name, that still reaches similarNameThreshold
+func maxNameDistance(length int) int {
+	if length == 0 {
+		return 0
+	}
+	distance := int((1-similarNameThreshold)*float64(length)) + 1
+	for distance > 0 && 1-float64(distance)/float64(length) < similarNameThreshold {
+		distance--
+	}
+	return distance
+}
+
+// nameSimilarity returns 1 minus the edit distance of names a and b relative
+// to the longer one, so 1 means equal and 0 means nothing in common, and
+// whether it reaches similarNameThreshold. Names that cannot reach it are
+// ruled out as early as possible, and their similarity is then 0.
+func nameSimilarity(a, b *foldedName) (float64, bool) {
+	longest := max(len(a.runes), len(b.runes))
+	if a.text == b.text {
+		return 1, true
+	}
+	limit := maxNameDistance(longest)
+
+	// Every edit changes the count of at most one class on each side, so it
+	// adds or removes at most one class on each side as well
+	if max(bits.OnesCount64(a.classes&^b.classes), bits.OnesCount64(b.classes&^a.classes)) > limit {
+		return 0, false
+	}
+	var more, fewer int
+	for c := range a.counts {
+		d := int(a.counts[c]) - int(b.counts[c])
+		more += max(d, 0)
+		fewer += max(-d, 0)
+	}
+	if max(more, fewer) > limit {
+		return 0, false
+	}
+
+	distance := boundedEditDistance(a.runes, b.runes, limit)
+	if distance > limit {
+		return 0, false
+	}
+	return 1 - float64(distance)/float64(longest), true
+}
+
+// boundedEditDistance is the Levenshtein distance between a and b if it is
+// at most limit, and limit+1 otherwise. Only the band of cells within limit
+// of the diagonal is computed, and it stops once a row exceeds limit.
+func boundedEditDistance(a, b []rune, limit int) int {
+	over := limit + 1
+	if abs(len(a)-len(b)) > limit {
+		return over
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = min(j, over)
+	}
+	for i := 1; i <= len(a); i++ {
+		lo, hi := max(1, i-limit), min(len(b), i+limit)
+		rowMin := over
+		if lo == 1 {
+			curr[0] = min(i, over)
+			rowMin = curr[0]
+		} else {
+			curr[lo-1] = over
+		}
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost, over)
+			rowMin = min(rowMin, curr[j])
+		}
+		if hi < len(b) {
+			curr[hi+1] = over
+		}
+		if rowMin >= over {
+			return over
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// disjointSet is a union-find over the indexes 0..n-1
+type disjointSet []int
+
+func newDisjointSet(n int) disjointSet {
+	set := make(disjointSet, n)
+	for i := range set {
+		set[i] = i
+	}
+	return set
+}
+
+func (d disjointSet) find(i int) int {
+	for d[i] != i {
+		d[i] = d[d[i]]
+		i = d[i]
+	}
+	return i
+}
+
+// union joins the sets of i and j, keeping the lower index as the root so
+// clusters are ordered by their first problem
+func (d disjointSet) union(i, j int) {
+	ri, rj := d.find(i), d.find(j)
+	if ri == rj {
+		return
+	}
+	if ri < rj {
+		d[rj] = ri
+	} else {
+		d[ri] = rj
+	}
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// createProblems creates problems, failing the test on the first error
+func createProblems(t *testing.T, svc *Service, problems ...*models.Problem) {
+	t.Helper()
+
+	for _, p := range problems {
+		if err := svc.CreateProblem(p); err != nil {
+			t.Fatalf("create %q: %v", p.Name, err)
+		}
+	}
+}
+
+func TestCreateProblemRejectsDuplicateLink(t *testing.T) {
+	svc := newTestService(t)
+	existing := &models.Problem{Name: "Copy of a Copy", Link: "https://codeforces.com/problemset/problem/1520/F", Difficulty: "Hard"}
+	other := &models.Problem{Name: "Other", Platform: "Codeforces", Difficulty: "Easy"}
+	createProblems(t, svc, existing, other)
+
+	// The contest link is the same problem as the problemset link
+	duplicate := &models.Problem{Name: "1520F", Link: "codeforces.com/contest/1520/problem/F", Difficulty: "Hard"}
+	var appErr *models.Error
+	if err := svc.CreateProblem(duplicate); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeConflict {
+		t.Fatalf("create duplicate: err = %v, want CONFLICT", err)
+	}
+
+	// Updating a problem keeps its own link, but cannot take another's
+	if err := svc.UpdateProblem(existing); err != nil {
+		t.Errorf("update with own link: %v", err)
+	}
+	other.Link = existing.Link
+	if err := svc.UpdateProblem(other); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeConflict {
+		t.Errorf("update to a taken link: err = %v, want CONFLICT", err)
+	}
+}
+
+func TestSimilarProblems(t *testing.T) {
+	svc := newTestService(t)
+	createProblems(t, svc,
+		&models.Problem{Name: "1. Two Sum", Platform: "LeetCode", Difficulty: "Easy"},
+		&models.Problem{Name: "Two Sum II", Platform: "LeetCode", Difficulty: "Medium"},
+		&models.Problem{Name: "Two Sum", Platform: "Codeforces", Difficulty: "Easy"},
+	)
+
+	problem := &models.Problem{Name: "Two  Sums", Platform: "LeetCode"}
+	matches, err := svc.SimilarProblems(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Problem.Name != "1. Two Sum" {
+		t.Fatalf("matches = %+v, want only LeetCode's Two Sum", matches)
+	}
+	if matches[0].Similarity < similarNameThreshold || matches[0].Similarity >= 1 {
+		t.Errorf("similarity = %v", matches[0].Similarity)
+	}
+}
+
+func TestFindDuplicatesAndMerge(t *testing.T) {
+	svc := newTestService(t)
+	a := &models.Problem{Name: "Shortest Routes I", Link: "https://cses.fi/problemset/task/1671", Difficulty: "Medium",
+		Notes: "Dijkstra", Tags: []models.Tag{{Name: "Graphs"}}}
+	b := &models.Problem{Name: "Shortest Route 1", Platform: "CSES", Difficulty: "Medium",
+		Notes: "Use a heap", CodeSnippet: "heap code", Tags: []models.Tag{{Name: "Shortest Paths"}}}
+	c := &models.Problem{Name: "Flight Routes", Platform: "CSES", Difficulty: "Hard"}
+	createProblems(t, svc, a, b, c)
+
+	// A duplicate from before the checks existed, which the unique index
+	// migration left without a canonical ID
+	d := &models.Problem{Name: "Dijkstra", Link: a.Link, Platform: "CSES", Difficulty: "Medium", Notes: "Dijkstra"}
+	var appErr *models.Error
+	if err := svc.repo.CreateProblem(d); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeConflict {
+		t.Fatalf("create with a taken platform problem ID: err = %v, want CONFLICT", err)
+	}
+	result, err := svc.db.Exec(`
+		INSERT INTO problems (name, link, platform, difficulty, notes, code_snippet, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, '', ?, ?)
+	`, d.Name, d.Link, d.Platform, d.Difficulty, d.Notes, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := result.LastInsertId()
+	d.ID = int(id)
+	if err := svc.AddAttempt(&models.Attempt{ProblemID: b.ID, Verdict: models.VerdictAccepted}); err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := svc.FindDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Reason != models.DuplicateLink {
+		t.Fatalf("clusters = %+v, want one link cluster", clusters)
+	}
+	var ids []int
+	for _, p := range clusters[0].Problems {
+		ids = append(ids, p.ID)
+	}
+	if want := []int{a.ID, b.ID, d.ID}; !slices.Equal(ids, want) {
+		t.Fatalf("cluster = %v, want %v", ids, want)
+	}
+
+	merged, err := svc.MergeProblems(a.ID, []int{b.ID, d.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Notes != "Dijkstra\n\nUse a heap" || merged.CodeSnippet != "heap code" {
+		t.Errorf("merged notes %q, code %q", merged.Notes, merged.CodeSnippet)
+	}
+	var tags []string
+	for _, tag := range merged.Tags {
+		tags = append(tags, tag.Name)
+	}
+	sort.Strings(tags)
+	if len(tags) != 2 || tags[0] != "Graphs" || tags[1] != "Shortest Paths" {
+		t.Errorf("merged tags = %v", tags)
+	}
+	if attempts, err := svc.GetAttempts(a.ID); err != nil || len(attempts) != 1 {
+		t.Errorf("merged attempts = %v, %v; want the dropped problem's attempt", attempts, err)
+	}
+	if _, err := svc.GetProblem(b.ID); err == nil {
+		t.Error("dropped problem still exists")
+	}
+
+	if clusters, err := svc.FindDuplicates(); err != nil || len(clusters) != 0 {
+		t.Errorf("clusters after merge = %+v, %v", clusters, err)
+	}
+}
+
+func TestDistinctLinksAreNotDuplicates(t *testing.T) {
+	svc := newTestService(t)
+	ii := &models.Problem{Name: "Combination Sum II", Link: "https://leetcode.com/problems/combination-sum-ii/", Difficulty: "Medium"}
+	iii := &models.Problem{Name: "Combination Sum III", Link: "https://leetcode.com/problems/combination-sum-iii/", Difficulty: "Medium"}
+	unlinked := &models.Problem{Name: "Combination Sum IV", Platform: "LeetCode", Difficulty: "Medium"}
+	createProblems(t, svc, ii, iii, unlinked)
+
+	matches, err := svc.SimilarProblems(iii)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Problem.ID != unlinked.ID {
+		t.Errorf("matches = %+v, want only the unlinked problem", matches)
+	}
+
+	clusters, err := svc.FindDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The unlinked problem joins both, but II and III are never compared
+	// with each other
+	if len(clusters) != 1 || len(clusters[0].Problems) != 3 {
+		t.Fatalf("clusters = %+v", clusters)
+	}
+
+	if err := svc.repo.DeleteProblem(unlinked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if clusters, err := svc.FindDuplicates(); err != nil || len(clusters) != 0 {
+		t.Errorf("clusters of distinct links = %+v, %v", clusters, err)
+	}
+}
+
+// editDistance is the full Levenshtein distance between a and b, as a
+// reference for boundedEditDistance
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func TestBoundedEditDistance(t *testing.T) {
+	names := []string{"", "a", "ab", "two sum", "two sums", "sum two", "three sum", "3sum",
+		"combination sum ii", "combination sum iii", "longest palindromic substring",
+		"longest palindromic subsequence", "最长回文子串", "最长回文子序列"}
+	for _, a := range names {
+		for _, b := range names {
+			ra, rb := []rune(a), []rune(b)
+			want := editDistance(ra, rb)
+			for limit := 0; limit <= 12; limit++ {
+				got := boundedEditDistance(ra, rb, limit)
+				if (want <= limit && got != want) || (want > limit && got != limit+1) {
+					t.Errorf("boundedEditDistance(%q, %q, %d) = %d, distance %d", a, b, limit, got, want)
+				}
+			}
+
+			// The pruned similarity decides the threshold like the full one
+			longest := max(len(ra), len(rb), 1)
+			full := 1 - float64(want)/float64(longest)
+			if _, ok := nameSimilarity(newFoldedName(a), newFoldedName(b)); ok != (full >= similarNameThreshold) {
+				t.Errorf("similarity(%q, %q) reaches the threshold = %v, full similarity %v", a, b, ok, full)
+			}
+		}
+	}
+}
+
+func TestSimilarNameCandidates(t *testing.T) {
+	// Names with typos, missing and extra words, so that many pairs are near
+	// the threshold
+	bases := []string{"two sum", "3sum closest", "longest palindromic substring", "word ladder ii",
+		"minimum path sum", "maximum subarray", "number of islands", "binary tree level order traversal"}
+	var names []*foldedName
+	for _, base := range bases {
+		r := []rune(base)
+		for n := 0; n < len(r); n++ {
+			names = append(names,
+				newFoldedName(string(r[:n])+string(r[n+1:])),
+				newFoldedName(string(r[:n])+"x"+string(r[n:])),
+				newFoldedName(string(r[:n])+"q"+string(r[n+1:])))
+		}
+		names = append(names, newFoldedName(base), newFoldedName(base+" ii"), newFoldedName("the "+base))
+	}
+	members := make([]int, len(names))
+	for i := range members {
+		members[i] = i
+	}
+
+	type pair struct{ i, j int }
+	candidates := make(map[pair]bool)
+	similarNameCandidates(names, members, func(i, j int) {
+		if i > j {
+			i, j = j, i
+		}
+		if candidates[pair{i, j}] {
+			t.Errorf("pair %q, %q listed twice", names[i].text, names[j].text)
+		}
+		candidates[pair{i, j}] = true
+	})
+
+	similar := 0
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if _, ok := nameSimilarity(names[i], names[j]); ok {
+				similar++
+				if !candidates[pair{i, j}] {
+					t.Errorf("similar names %q and %q are not candidates", names[i].text, names[j].text)
+				}
+			}
+		}
+	}
+	if similar == 0 || len(candidates) >= len(names)*(len(names)-1)/2 {
+		t.Errorf("%d similar pairs among %d candidates", similar, len(candidates))
+	}
+}
+
+// BenchmarkFindDuplicates scans 10000 problems on one platform whose names
+// are drawn from a small vocabulary, so many have similar lengths and letters
+func BenchmarkFindDuplicates(b *testing.B) {
+	db, err := database.Open(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	svc := NewService(db)
+	b.Cleanup(func() { svc.Close() })
+
+	words := []string{"two", "sum", "longest", "path", "tree", "graph", "minimum", "maximum", "subarray",
+		"string", "binary", "search", "number", "of", "islands", "valid", "word", "ladder", "cost", "jump"}
+	tx, err := db.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	now := time.Now()
+	for p := 1; p <= 10000; p++ {
+		name := fmt.Sprintf("%s %s %s %d", words[p%len(words)], words[p/7%len(words)], words[p/131%len(words)], p)
+		if _, err := tx.Exec(`
+			INSERT INTO problems (id, name, link, platform, difficulty, solve_time, notes, code_snippet, created_at, updated_at)
+			VALUES (?, ?, '', 'LeetCode', 'Medium', 30, '', '', ?, ?)
+		`, p, name, now, now); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := svc.FindDuplicates(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/repository"
+)
+
+// importRow is a validated problem and where it came from in the import file
+type importRow struct {
+	row     int
+	problem *models.Problem
+}
+
+// ImportFromJSON imports a JSON archive written by ExportToJSON, or a plain
+// JSON array of problems as written by earlier versions. Problems that fail
+// validation are reported and skipped; the rest are imported in one
+// transaction, handling existing problems with the options' strategy.
+func (s *Service) ImportFromJSON(filePath string, options *models.ImportOptions) (*models.ImportResult, error) {
+	options, err := normalizeImportOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return s.importArchive(data, options)
+	}
+
+	var problems []models.Problem
+	if err := json.Unmarshal(data, &problems); err != nil {
+		return nil, models.WrapError(models.ErrCodeValidation, err, "invalid JSON: %v", err)
+	}
+
+	result := &models.ImportResult{DryRun: options.DryRun, Total: len(problems)}
+	var rows []importRow
+	for i := range problems {
+		problem := &problems[i]
+		problem.ID = 0
+		normalizeProblem(problem)
+		if err := s.validateProblem(problem); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, importRowError(i+1, err))
+			continue
+		}
+		rows = append(rows, importRow{row: i + 1, problem: problem})
+	}
+
+	if err := s.importRows(rows, options, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importArchive imports every tag, portable setting and valid problem of an
+// archive
+func (s *Service) importArchive(data []byte, options *models.ImportOptions) (*models.ImportResult, error) {
+	var archive models.Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, models.WrapError(models.ErrCodeValidation, err, "invalid archive: %v", err)
+	}
+	if archive.FormatVersion < 1 {
+		return nil, models.NewError(models.ErrCodeValidation, "archive has no format_version")
+	}
+	if archive.FormatVersion > models.ArchiveFormatVersion {
+		return nil, models.NewError(models.ErrCodeValidation,
+			"archive format version %d is newer than the supported version %d",
+			archive.FormatVersion, models.ArchiveFormatVersion)
+	}
+
+	result := &models.ImportResult{DryRun: options.DryRun, Total: len(archive.Problems)}
+	valid := archive
+	valid.Problems = nil
+	valid.Settings = portableSettingValues(archive.Settings)
+	var rows []int
+	for i, p := range archive.Problems {
+		problem := &models.Problem{Name: p.Name, Link: p.Link, Platform: p.Platform, Status: p.Status,
+			Difficulty: p.Difficulty, RawDifficulty: p.RawDifficulty}
+		normalizeProblem(problem)
+		err := s.validateProblem(problem)
+		if err == nil {
+			err = validateStatusHistory(p.StatusHistory)
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, importRowError(i+1, err))
+			continue
+		}
+		// Archives from before statuses have none, meaning solved
+		if problem.Status == "" {
+			problem.Status = models.StatusSolved
+		}
+		p.Link, p.Platform, p.Status = problem.Link, problem.Platform, problem.Status
+		// Archives from before difficulty scales only have a level
+		p.Difficulty, p.RawDifficulty = problem.Difficulty, problem.RawDifficulty
+		if p.DifficultyScore == 0 {
+			p.DifficultyScore = problem.DifficultyScore
+		}
+		valid.Problems = append(valid.Problems, p)
+		rows = append(rows, i+1)
+	}
+
+	outcomes, errs, err := s.repo.ImportArchive(&valid, options.Strategy, options.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	tallyImport(result, rows, outcomes, errs)
+	return result, nil
+}
+
+// portableSettings are the settings an archive carries to another machine.
+// The backup policy names a directory and schedule on this machine, so it is
+// neither exported nor imported.
+var portableSettings = map[string]bool{
+	timezoneSettingsKey: true,
+}
+
+// portableSettingValues returns the portable settings among values, or nil
+// if there are none
+func portableSettingValues(values map[string]string) map[string]string {
+	var portable map[string]string
+	for key, value := range values {
+		if !portableSettings[key] {
+			continue
+		}
+		if portable == nil {
+			portable = make(map[string]string)
+		}
+		portable[key] = value
+	}
+	return portable
+}
+
+// validateStatusHistory checks that an archived status history only moves
+// between known statuses. The first change of a problem comes from no status.
+func validateStatusHistory(history []models.ArchiveStatus) error {
+	for _, c := range history {
+		if (c.FromStatus != "" && !validStatus(c.FromStatus)) || !validStatus(c.ToStatus) {
+			return models.NewValidationError([]models.FieldError{{Field: "status_history", Message: statusMessage}})
+		}
+	}
+	return nil
+}
+
+// importRows writes validated rows in one transaction and adds the outcome of
+// each to result. Problems that already exist, by link or else by name and
+// platform, are handled with the options' strategy. A dry run rolls the
+// transaction back, so result shows what the import would do.
+func (s *Service) importRows(rows []importRow, options *models.ImportOptions, result *models.ImportResult) error {
+	problems := make([]*models.Problem, len(rows))
+	for i, row := range rows {
+		problems[i] = row.problem
+	}
+
+	outcomes, errs, err := s.repo.ImportProblems(problems, options.Strategy, options.DryRun)
+	if err != nil {
+		return err
+	}
+
+	positions := make([]int, len(rows))
+	for i, row := range rows {
+		positions[i] = row.row
+	}
+	tallyImport(result, positions, outcomes, errs)
+	return nil
+}
+
+// tallyImport adds the outcome of each imported row to result
+func tallyImport(result *models.ImportResult, rows []int, outcomes []repository.ImportOutcome, errs []error) {
+	for i, outcome := range outcomes {
+		if errs[i] != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, importRowError(rows[i], errs[i]))
+			continue
+		}
+		switch outcome {
+		case repository.ImportCreated:
+			result.Created++
+		case repository.ImportUpdated:
+			result.Updated++
+		case repository.ImportSkipped:
+			result.Skipped++
+		}
+	}
+}
+
+// normalizeImportOptions fills in defaults and validates the strategy
+func normalizeImportOptions(options *models.ImportOptions) (*models.ImportOptions, error) {
+	normalized := models.ImportOptions{}
+	if options != nil {
+		normalized = *options
+	}
+	if normalized.Strategy == "" {
+		normalized.Strategy = models.ImportSkip
+	}
+
+	switch normalized.Strategy {
+	case models.ImportSkip, models.ImportOverwrite, models.ImportMergeTags, models.ImportKeepBoth:
+		return &normalized, nil
+	}
+	return nil, models.NewValidationError([]models.FieldError{{
+		Field:   "strategy",
+		Message: "strategy must be skip, overwrite, merge_tags, or keep_both",
+	}})
+}
+
+// importRowError describes a failed row, keeping field details of validation errors
+func importRowError(row int, err error) models.ImportRowError {
+	rowErr := models.ImportRowError{Row: row, Message: err.Error()}
+	var appErr *models.Error
+	if errors.As(err, &appErr) {
+		rowErr.Fields = appErr.Fields
+	}
+	return rowErr
+}
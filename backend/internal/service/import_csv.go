@@ -0,0 +1,258 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// csvTagSeparator separates tag names within the Tags column
+const csvTagSeparator = ";"
+
+// utf8BOM is the byte order mark spreadsheet programs put before the header
+const utf8BOM = "\ufeff"
+
+// csvFields lists the importable problem fields with the column header the
+// CSV export writes for each
+var csvFields = []struct {
+	field  string
+	header string
+}{
+	{"name", "Name"},
+	{"link", "Link"},
+	{"platform", "Platform"},
+	{"difficulty", "Difficulty"},
+	{"solve_time", "SolveTime"},
+	{"tags", "Tags"},
+	{"notes", "Notes"},
+	{"code_snippet", "CodeSnippet"},
+	{"status", "Status"},
+	{"created_at", "CreatedAt"},
+	{"updated_at", "UpdatedAt"},
+}
+
+// csvRequiredFields must be present as columns for an import to start
+var csvRequiredFields = []string{"name", "platform", "difficulty"}
+
+// csvTimeFormats are the accepted timestamp formats, the export's first
+var csvTimeFormats = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ImportFromCSV imports problems from a CSV file with a header row. Rows that
+// fail validation are reported and skipped; the rest are imported as
+// described by importRows.
+func (s *Service) ImportFromCSV(filePath string, options *models.ImportOptions) (*models.ImportResult, error) {
+	options, err := normalizeImportOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, models.NewError(models.ErrCodeValidation, "CSV file is empty")
+	}
+	if err != nil {
+		return nil, models.WrapError(models.ErrCodeValidation, err, "invalid CSV: %v", err)
+	}
+
+	columns, err := resolveCSVColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ImportResult{DryRun: options.DryRun}
+	var rows []importRow
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			// A malformed quote leaves the reader unable to find the next row
+			result.Total++
+			result.Failed++
+			result.Errors = append(result.Errors, models.ImportRowError{
+				Row:     parseErr.StartLine,
+				Message: parseErr.Err.Error() + "; the rest of the file was not read",
+			})
+			break
+		}
+
+		result.Total++
+		line, _ := reader.FieldPos(0)
+		problem, err := s.parseCSVRecord(record, columns)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, importRowError(line, err))
+			continue
+		}
+		rows = append(rows, importRow{row: line, problem: problem})
+	}
+
+	if err := s.importRows(rows, options, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveCSVColumns maps each problem field to its column index in header.
+// Header names are matched case-insensitively.
+func resolveCSVColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	known := make(map[string]bool, len(csvFields))
+	for _, f := range csvFields {
+		known[f.field] = true
+	}
+
+	var fields []models.FieldError
+	for field := range mapping {
+		if !known[field] {
+			fields = append(fields, models.FieldError{Field: field, Message: "unknown field " + field + " in column mapping"})
+		}
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, utf8BOM)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for _, f := range csvFields {
+		name, mapped := mapping[f.field]
+		if !mapped {
+			name = f.header
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if ok {
+			columns[f.field] = i
+		} else if mapped {
+			fields = append(fields, models.FieldError{Field: f.field, Message: "column " + name + " not found"})
+		}
+	}
+	for _, field := range csvRequiredFields {
+		if _, ok := columns[field]; !ok {
+			if _, mapped := mapping[field]; !mapped {
+				fields = append(fields, models.FieldError{Field: field, Message: "no column for " + field})
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, models.NewValidationError(fields)
+	}
+	return columns, nil
+}
+
+// parseCSVRecord builds a problem from a record and validates it
+func (s *Service) parseCSVRecord(record []string, columns map[string]int) (*models.Problem, error) {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	problem := &models.Problem{
+		Name:        value("name"),
+		Link:        value("link"),
+		Platform:    value("platform"),
+		Difficulty:  value("difficulty"),
+		Notes:       value("notes"),
+		CodeSnippet: value("code_snippet"),
+		Status:      value("status"),
+		Tags:        parseCSVTags(value("tags")),
+	}
+
+	var fields []models.FieldError
+	if raw := value("solve_time"); raw != "" {
+		solveTime, err := strconv.Atoi(raw)
+		if err != nil || solveTime < 0 {
+			fields = append(fields, models.FieldError{Field: "solve_time", Message: "solve time must be a non-negative number of minutes"})
+		}
+		problem.SolveTime = solveTime
+	}
+	for _, ts := range []struct {
+		field  string
+		target *models.CustomTime
+	}{
+		{"created_at", &problem.CreatedAt},
+		{"updated_at", &problem.UpdatedAt},
+	} {
+		if raw := value(ts.field); raw != "" {
+			t, ok := parseCSVTime(raw)
+			if !ok {
+				fields = append(fields, models.FieldError{Field: ts.field, Message: ts.field + " is not a valid date"})
+			}
+			ts.target.Time = t
+		}
+	}
+
+	normalizeProblem(problem)
+	if err := s.validateProblem(problem); err != nil {
+		var validation *models.Error
+		if !errors.As(err, &validation) {
+			return nil, err
+		}
+		fields = append(validation.Fields, fields...)
+	}
+	if len(fields) > 0 {
+		return nil, models.NewValidationError(fields)
+	}
+	return problem, nil
+}
+
+// parseCSVTags splits a Tags cell on the tag separator, dropping blanks and
+// repeated names
+func parseCSVTags(value string) []models.Tag {
+	var tags []models.Tag
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, csvTagSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, models.Tag{Name: name})
+	}
+	return tags
+}
+
+// parseCSVTime parses a timestamp in local time
+func parseCSVTime(value string) (time.Time, bool) {
+	for _, format := range csvTimeFormats {
+		if t, err := time.ParseInLocation(format, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// writeCSV writes content to a file in a temporary directory
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "import.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportFromCSVRoundTrip(t *testing.T) {
+	source := newTestService(t)
+	problem := &models.Problem{
+		Name:       "Two Sum",
+		Link:       "https://leetcode.com/problems/two-sum/",
+		Platform:   "LeetCode",
+		Difficulty: "Easy",
+		SolveTime:  12,
+		Notes:      "hash map,\n\"one pass\"",
+		Tags:       []models.Tag{{Name: "array"}, {Name: "hash table"}},
+	}
+	if err := source.CreateProblem(problem); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := source.ExportToCSV(path); err != nil {
+		t.Fatal(err)
+	}
+
+	target := newTestService(t)
+	result, err := target.ImportFromCSV(path, &models.ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || result.Failed != 0 {
+		t.Fatalf("dry run result = %+v", result)
+	}
+	if problems, _ := target.GetProblems(nil); len(problems) != 0 {
+		t.Fatalf("dry run wrote %d problems", len(problems))
+	}
+
+	if result, err = target.ImportFromCSV(path, nil); err != nil || result.Created != 1 {
+		t.Fatalf("import result = %+v, err %v", result, err)
+	}
+	problems, err := target.GetProblems(nil)
+	if err != nil || len(problems) != 1 {
+		t.Fatalf("got %d problems, err %v", len(problems), err)
+	}
+	got := problems[0]
+	if got.Name != problem.Name || got.Link != problem.Link || got.Notes != problem.Notes || got.SolveTime != problem.SolveTime {
+		t.Errorf("imported %+v, want %+v", got, problem)
+	}
+	if names := []string{got.Tags[0].Name, got.Tags[1].Name}; !reflect.DeepEqual(names, []string{"array", "hash table"}) {
+		t.Errorf("imported tags %v", names)
+	}
+}
+
+func TestImportFromCSVMappingAndRowErrors(t *testing.T) {
+	svc := newTestService(t)
+	path := writeCSV(t, "Title,Site,Level,Minutes,Topics\n"+
+		"Good,Codeforces,medium,30,dp; graphs;;dp\n"+
+		",Codeforces,Hard,x,\n"+
+		"Bad level,AtCoder,Insane,5,\n")
+
+	options := &models.ImportOptions{
+		Mapping: map[string]string{
+			"name":       "Title",
+			"platform":   "Site",
+			"difficulty": "Level",
+			"solve_time": "Minutes",
+			"tags":       "Topics",
+		},
+		DryRun: true,
+	}
+	result, err := svc.ImportFromCSV(path, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.Created != 1 || result.Failed != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	var rows []int
+	var fields [][]string
+	for _, rowErr := range result.Errors {
+		rows = append(rows, rowErr.Row)
+		var names []string
+		for _, f := range rowErr.Fields {
+			names = append(names, f.Field)
+		}
+		fields = append(fields, names)
+	}
+	if !reflect.DeepEqual(rows, []int{3, 4}) {
+		t.Errorf("error rows = %v, want [3 4]", rows)
+	}
+	if !reflect.DeepEqual(fields, [][]string{{"name", "solve_time"}, {"difficulty"}}) {
+		t.Errorf("error fields = %v", fields)
+	}
+
+	options.DryRun = false
+	if _, err := svc.ImportFromCSV(path, options); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := svc.GetProblems(nil)
+	if err != nil || len(problems) != 1 {
+		t.Fatalf("got %d problems, err %v", len(problems), err)
+	}
+	if problems[0].Difficulty != "Medium" || len(problems[0].Tags) != 2 {
+		t.Errorf("imported %+v", problems[0])
+	}
+
+	options.Mapping["name"] = "Missing"
+	if _, err := svc.ImportFromCSV(path, options); err == nil {
+		t.Error("mapping to a missing column was accepted")
+	}
+}
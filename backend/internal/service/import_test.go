@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// writeJSON writes problems as a JSON import file
+func writeJSON(t *testing.T, problems []models.Problem) string {
+	t.Helper()
+
+	data, err := json.Marshal(problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "import.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tagNames returns the sorted tag names of a problem
+func tagNames(problem models.Problem) []string {
+	var names []string
+	for _, tag := range problem.Tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestImportStrategies(t *testing.T) {
+	created := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	original := []models.Problem{
+		{Name: "Two Sum", Link: "https://leetcode.com/problems/two-sum/", Platform: "LeetCode", Difficulty: "Easy",
+			Tags: []models.Tag{{Name: "array"}}, CreatedAt: models.CustomTime{Time: created}},
+		{Name: "Frog 1", Platform: "AtCoder", Difficulty: "Easy", Tags: []models.Tag{{Name: "dp"}},
+			CreatedAt: models.CustomTime{Time: created}},
+	}
+	changed := []models.Problem{
+		{Name: "Two Sum (renamed)", Link: "https://leetcode.com/problems/two-sum/", Platform: "LeetCode", Difficulty: "Medium",
+			Tags: []models.Tag{{Name: "hash table"}}},
+		{Name: "frog 1", Platform: "AtCoder", Difficulty: "Easy", Tags: []models.Tag{{Name: "dp"}}},
+	}
+
+	tests := []struct {
+		strategy string
+		want     models.ImportResult
+		problems int
+		names    []string
+		tags     []string // tags of Two Sum afterwards
+	}{
+		{models.ImportSkip, models.ImportResult{Total: 2, Skipped: 2}, 2, []string{"Frog 1", "Two Sum"}, []string{"array"}},
+		{models.ImportOverwrite, models.ImportResult{Total: 2, Updated: 2}, 2, []string{"Two Sum (renamed)", "frog 1"}, []string{"hash table"}},
+		{models.ImportMergeTags, models.ImportResult{Total: 2, Updated: 1, Skipped: 1}, 2, []string{"Frog 1", "Two Sum"}, []string{"array", "hash table"}},
+		{models.ImportKeepBoth, models.ImportResult{Total: 2, Created: 2}, 4, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			svc := newTestService(t)
+			first, err := svc.ImportFromJSON(writeJSON(t, original), nil)
+			if err != nil || first.Created != 2 {
+				t.Fatalf("first import = %+v, err %v", first, err)
+			}
+
+			path := writeJSON(t, changed)
+			dryRun, err := svc.ImportFromJSON(path, &models.ImportOptions{Strategy: tt.strategy, DryRun: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := svc.ImportFromJSON(path, &models.ImportOptions{Strategy: tt.strategy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dryRun.DryRun = false
+			if !reflect.DeepEqual(*result, tt.want) || !reflect.DeepEqual(*dryRun, tt.want) {
+				t.Errorf("result = %+v, dry run = %+v, want %+v", *result, *dryRun, tt.want)
+			}
+
+			problems, err := svc.GetProblems(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(problems, func(i, j int) bool { return problems[i].ID < problems[j].ID })
+			if len(problems) != tt.problems {
+				t.Fatalf("got %d problems, want %d", len(problems), tt.problems)
+			}
+			if tt.names == nil {
+				return
+			}
+			names := []string{problems[0].Name, problems[1].Name}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %v, want %v", names, tt.names)
+			}
+			if tags := tagNames(problems[0]); !reflect.DeepEqual(tags, tt.tags) {
+				t.Errorf("Two Sum tags = %v, want %v", tags, tt.tags)
+			}
+		})
+	}
+}
+
+func TestImportPreservesTimestamps(t *testing.T) {
+	svc := newTestService(t)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+	path := writeJSON(t, []models.Problem{{
+		Name: "Old", Platform: "Codeforces", Difficulty: "Hard",
+		CreatedAt: models.CustomTime{Time: created},
+		UpdatedAt: models.CustomTime{Time: updated},
+	}})
+
+	if _, err := svc.ImportFromJSON(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := svc.ImportFromJSON(path, nil)
+	if err != nil || result.Skipped != 1 || result.Created != 0 {
+		t.Fatalf("repeated import = %+v, err %v", result, err)
+	}
+
+	problems, err := svc.GetProblems(nil)
+	if err != nil || len(problems) != 1 {
+		t.Fatalf("got %d problems, err %v", len(problems), err)
+	}
+	if !problems[0].CreatedAt.Equal(created) || !problems[0].UpdatedAt.Equal(updated) {
+		t.Errorf("timestamps = %v / %v, want %v / %v", problems[0].CreatedAt, problems[0].UpdatedAt, created, updated)
+	}
+}
+
+func TestImportMatchesPlatformProblemID(t *testing.T) {
+	svc := newTestService(t)
+	// A link stored before links were normalized
+	existing := &models.Problem{Name: "Binary Search", Link: "https://codeforces.com/contest/1520/problem/F",
+		Platform: "Codeforces", Difficulty: "Hard"}
+	if err := svc.repo.CreateProblem(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeJSON(t, []models.Problem{{Name: "Guess the K-th Zero", Link: "https://codeforces.com/problemset/problem/1520/F",
+		Difficulty: "Hard"}})
+	result, err := svc.ImportFromJSON(path, nil)
+	if err != nil || result.Skipped != 1 || result.Created != 0 {
+		t.Fatalf("import of the same problem = %+v, err %v", result, err)
+	}
+	result, err = svc.ImportFromJSON(path, &models.ImportOptions{Strategy: models.ImportOverwrite})
+	if err != nil || result.Updated != 1 || len(result.Errors) != 0 {
+		t.Fatalf("overwrite = %+v, err %v", result, err)
+	}
+	if problem, err := svc.GetProblem(existing.ID); err != nil || problem.Name != "Guess the K-th Zero" {
+		t.Errorf("overwritten problem = %+v, %v", problem, err)
+	}
+}
+
+func TestImportRejectsUnknownStrategy(t *testing.T) {
+	svc := newTestService(t)
+	if _, err := svc.ImportFromJSON(writeJSON(t, nil), &models.ImportOptions{Strategy: "replace"}); err == nil {
+		t.Error("unknown strategy was accepted")
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// defaultNotebookTemplates are the built-in notebook templates, overridable
+// per file through NotebookOptions.TemplateDir
+//
+//go:embed templates/notebook/*.tmpl
+var defaultNotebookTemplates embed.FS
+
+const (
+	defaultNotebookTitle = "Solution Notebook"
+
+	// untaggedGroup holds problems without tags when grouping by tag
+	untaggedGroup = "Untagged"
+)
+
+// notebookProblem is a problem as seen by the notebook templates
+type notebookProblem struct {
+	models.Problem
+	TagNames []string
+	Language string // fence language detected from the code snippet
+	Fence    string // backtick fence longer than any run in the code
+}
+
+// notebookGroup is one page of the notebook
+type notebookGroup struct {
+	Title    string // notebook title
+	Name     string
+	Path     string // page path relative to the index
+	Problems []notebookProblem
+}
+
+// notebookIndex is the data of the index page
+type notebookIndex struct {
+	Title   string
+	GroupBy string
+	Total   int
+	Groups  []notebookGroup
+}
+
+// notebookRenderer executes a named template into a buffer
+type notebookRenderer func(name string, data interface{}) ([]byte, error)
+
+// ExportNotebook writes the problems matching the options' filter into dir
+// as a Markdown notebook or a static HTML site: an index page linking one
+// page per tag or platform, each listing its problems with notes, link and
+// code.
+func (s *Service) ExportNotebook(dir string, options *models.NotebookOptions) error {
+	opts, err := normalizeNotebookOptions(options)
+	if err != nil {
+		return err
+	}
+
+	render, err := loadNotebookTemplates(opts.Format, opts.TemplateDir)
+	if err != nil {
+		return err
+	}
+
+	problems, err := s.GetProblems(opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	ext := ".md"
+	if opts.Format == models.NotebookHTML {
+		ext = ".html"
+	}
+	groups := groupNotebookProblems(problems, opts.GroupBy, opts.Title, ext)
+
+	for _, group := range groups {
+		page, err := render("group"+ext+".tmpl", group)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(group.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, page, 0o644); err != nil {
+			return err
+		}
+	}
+
+	index, err := render("index"+ext+".tmpl", notebookIndex{
+		Title:   opts.Title,
+		GroupBy: opts.GroupBy,
+		Total:   len(problems),
+		Groups:  groups,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "index"+ext), index, 0o644)
+}
+
+// normalizeNotebookOptions fills in defaults and validates the options
+func normalizeNotebookOptions(options *models.NotebookOptions) (*models.NotebookOptions, error) {
+	opts := models.NotebookOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Format == "" {
+		opts.Format = models.NotebookMarkdown
+	}
+	if opts.GroupBy == "" {
+		opts.GroupBy = models.NotebookGroupByTag
+	}
+	if opts.Title == "" {
+		opts.Title = defaultNotebookTitle
+	}
+
+	var fields []models.FieldError
+	if opts.Format != models.NotebookMarkdown && opts.Format != models.NotebookHTML {
+		fields = append(fields, models.FieldError{Field: "format", Message: "format must be markdown or html"})
+	}
+	if opts.GroupBy != models.NotebookGroupByTag && opts.GroupBy != models.NotebookGroupByPlatform {
+		fields = append(fields, models.FieldError{Field: "group_by", Message: "group_by must be tag or platform"})
+	}
+	if len(fields) > 0 {
+		return nil, models.NewValidationError(fields)
+	}
+	return &opts, nil
+}
+
+// loadNotebookTemplates parses the index and group templates of format,
+// preferring files in templateDir over the built-in ones. Markdown uses
+// text/template and HTML uses html/template so that problem text is escaped.
+func loadNotebookTemplates(format, templateDir string) (notebookRenderer, error) {
+	ext := ".md.tmpl"
+	if format == models.NotebookHTML {
+		ext = ".html.tmpl"
+	}
+	funcs := map[string]interface{}{"join": strings.Join}
+
+	var text *texttemplate.Template
+	var html *htmltemplate.Template
+	if format == models.NotebookHTML {
+		html = htmltemplate.New("notebook").Funcs(funcs)
+	} else {
+		text = texttemplate.New("notebook").Funcs(funcs)
+	}
+
+	for _, name := range []string{"index" + ext, "group" + ext} {
+		source, err := readNotebookTemplate(name, templateDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if html != nil {
+			_, err = html.New(name).Parse(source)
+		} else {
+			_, err = text.New(name).Parse(source)
+		}
+		if err != nil {
+			return nil, models.WrapError(models.ErrCodeValidation, err, "invalid notebook template %s: %v", name, err)
+		}
+	}
+
+	return func(name string, data interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		var err error
+		if html != nil {
+			err = html.ExecuteTemplate(&buf, name, data)
+		} else {
+			err = text.ExecuteTemplate(&buf, name, data)
+		}
+		if err != nil {
+			return nil, models.WrapError(models.ErrCodeValidation, err, "cannot render notebook template %s: %v", name, err)
+		}
+		return buf.Bytes(), nil
+	}, nil
+}
+
+// readNotebookTemplate returns the override of name in templateDir, or the
+// built-in template
+func readNotebookTemplate(name, templateDir string) (string, error) {
+	if templateDir != "" {
+		source, err := os.ReadFile(filepath.Join(templateDir, name))
+		if err == nil {
+			return string(source), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	source, err := defaultNotebookTemplates.ReadFile("templates/notebook/" + name)
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
+
+// groupNotebookProblems groups problems by tag or platform. Groups are sorted
+// by name, with untagged problems last, and problems within a group by name.
+// A problem with several tags appears in each of their groups.
+func groupNotebookProblems(problems []models.Problem, groupBy, title, ext string) []notebookGroup {
+	byName := make(map[string][]notebookProblem)
+	for _, p := range problems {
+		np := notebookProblem{Problem: p}
+		for _, tag := range p.Tags {
+			np.TagNames = append(np.TagNames, tag.Name)
+		}
+		np.Language = detectLanguage(p.CodeSnippet)
+		np.Fence = codeFence(p.CodeSnippet)
+
+		var keys []string
+		if groupBy == models.NotebookGroupByPlatform {
+			keys = []string{p.Platform}
+		} else if keys = np.TagNames; len(keys) == 0 {
+			keys = []string{untaggedGroup}
+		}
+		for _, key := range keys {
+			byName[key] = append(byName[key], np)
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == untaggedGroup) != (names[j] == untaggedGroup) {
+			return names[j] == untaggedGroup
+		}
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	pageDir := "tags/"
+	if groupBy == models.NotebookGroupByPlatform {
+		pageDir = "platforms/"
+	}
+	used := make(map[string]bool)
+	groups := make([]notebookGroup, 0, len(names))
+	for _, name := range names {
+		members := byName[name]
+		sort.SliceStable(members, func(i, j int) bool {
+			return strings.ToLower(members[i].Name) < strings.ToLower(members[j].Name)
+		})
+		groups = append(groups, notebookGroup{
+			Title:    title,
+			Name:     name,
+			Path:     pageDir + uniqueSlug(name, used) + ext,
+			Problems: members,
+		})
+	}
+	return groups
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// uniqueSlug turns name into a file name not yet in used
+func uniqueSlug(name string, used map[string]bool) string {
+	base := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "group"
+	}
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	used[slug] = true
+	return slug
+}
+
+// codeFence returns a backtick fence longer than any backtick run in code
+func codeFence(code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+// languageHints maps fence languages to snippets that give them away. The
+// first language with a matching hint wins, so more specific ones come first.
+var languageHints = []struct {
+	language string
+	hints    []string
+}{
+	{"cpp", []string{"#include <bits/stdc++.h>", "#include <iostream>", "using namespace std", "std::", "cout <<", "cin >>"}},
+	{"c", []string{"#include <stdio.h>", "#include <stdlib.h>", "printf(", "scanf("}},
+	{"java", []string{"public static void main", "System.out.", "import java.", "public class "}},
+	{"csharp", []string{"using System", "Console.Write", "static void Main"}},
+	{"rust", []string{"fn main()", "let mut ", "impl ", "use std::"}},
+	{"go", []string{"package main", "func main()", "fmt.Print", ":= "}},
+	{"kotlin", []string{"fun main(", "readLine()!!"}},
+	{"python", []string{"def ", "import sys", "print(", "elif ", "range("}},
+	{"javascript", []string{"console.log", "function ", "=> ", "const ", "let "}},
+}
+
+// detectLanguage guesses the fence language of a code snippet, or returns ""
+func detectLanguage(code string) string {
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	for _, lang := range languageHints {
+		for _, hint := range lang.hints {
+			if strings.Contains(code, hint) {
+				return lang.language
+			}
+		}
+	}
+	return ""
+}
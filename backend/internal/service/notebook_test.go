@@ -0,0 +1,149 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// readFile returns the contents of a file, failing the test if it is missing
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// seedNotebook creates problems covering tags, links and code snippets
+func seedNotebook(t *testing.T, svc *Service) {
+	t.Helper()
+
+	problems := []*models.Problem{
+		{Name: "Shortest Routes I", Link: "https://cses.fi/problemset/task/1671", Platform: "CSES", Difficulty: "Medium",
+			Notes: "Dijkstra with a <min-heap>", CodeSnippet: "#include <bits/stdc++.h>\nusing namespace std;",
+			Tags: []models.Tag{{Name: "Graphs"}, {Name: "Shortest Paths"}}},
+		{Name: "Markdown in code", Platform: "LeetCode", Difficulty: "Easy",
+			CodeSnippet: "def f():\n    return \"```\"", Tags: []models.Tag{{Name: "Graphs"}}},
+		{Name: "Loose end", Platform: "AtCoder", Difficulty: "Hard"},
+	}
+	for _, p := range problems {
+		if err := svc.CreateProblem(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportNotebookMarkdown(t *testing.T) {
+	svc := newTestService(t)
+	seedNotebook(t, svc)
+	dir := t.TempDir()
+
+	if err := svc.ExportNotebook(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	index := readFile(t, filepath.Join(dir, "index.md"))
+	for _, want := range []string{
+		"# Solution Notebook",
+		"- [Graphs](tags/graphs.md) (2)",
+		"- [Shortest Paths](tags/shortest-paths.md) (1)",
+		"- [Untagged](tags/untagged.md) (1)",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index.md is missing %q:\n%s", want, index)
+		}
+	}
+	if strings.Index(index, "Untagged") < strings.Index(index, "Shortest Paths") {
+		t.Errorf("untagged problems should be listed last:\n%s", index)
+	}
+
+	graphs := readFile(t, filepath.Join(dir, "tags", "graphs.md"))
+	for _, want := range []string{
+		"- Link: <https://cses.fi/problemset/task/1671>",
+		"- Tags: Graphs, Shortest Paths",
+		"```cpp\n#include <bits/stdc++.h>",
+		"````python\ndef f():",
+	} {
+		if !strings.Contains(graphs, want) {
+			t.Errorf("graphs.md is missing %q:\n%s", want, graphs)
+		}
+	}
+}
+
+func TestExportNotebookHTMLByPlatform(t *testing.T) {
+	svc := newTestService(t)
+	seedNotebook(t, svc)
+	dir := t.TempDir()
+
+	options := &models.NotebookOptions{Format: models.NotebookHTML, GroupBy: models.NotebookGroupByPlatform}
+	if err := svc.ExportNotebook(dir, options); err != nil {
+		t.Fatal(err)
+	}
+
+	if index := readFile(t, filepath.Join(dir, "index.html")); !strings.Contains(index, `href="platforms/cses.html"`) {
+		t.Errorf("index.html does not link the CSES page:\n%s", index)
+	}
+	page := readFile(t, filepath.Join(dir, "platforms", "cses.html"))
+	for _, want := range []string{
+		"Dijkstra with a &lt;min-heap&gt;",
+		`<code class="language-cpp">#include &lt;bits/stdc&#43;&#43;.h&gt;`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("cses.html is missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestExportNotebookTemplateOverride(t *testing.T) {
+	svc := newTestService(t)
+	seedNotebook(t, svc)
+
+	templates := t.TempDir()
+	override := "{{range .Groups}}{{.Name}}={{len .Problems}}\n{{end}}"
+	if err := os.WriteFile(filepath.Join(templates, "index.md.tmpl"), []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := svc.ExportNotebook(dir, &models.NotebookOptions{TemplateDir: templates}); err != nil {
+		t.Fatal(err)
+	}
+	if index := readFile(t, filepath.Join(dir, "index.md")); index != "Graphs=2\nShortest Paths=1\nUntagged=1\n" {
+		t.Errorf("overridden index.md = %q", index)
+	}
+	// The group template was not overridden
+	if page := readFile(t, filepath.Join(dir, "tags", "untagged.md")); !strings.HasPrefix(page, "# Untagged") {
+		t.Errorf("untagged.md = %q", page)
+	}
+
+	if err := os.WriteFile(filepath.Join(templates, "group.md.tmpl"), []byte("{{.Missing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ExportNotebook(dir, &models.NotebookOptions{TemplateDir: templates}); err == nil {
+		t.Error("a broken template was accepted")
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"#include <bits/stdc++.h>\nint main() {}":                      "cpp",
+		"#include <stdio.h>\nint main() { printf(\"x\"); }":            "c",
+		"public class Main { public static void main(String[] a) {} }": "java",
+		"fn main() {\n    let mut x = 1;\n}":                           "rust",
+		"package main\n\nfunc main() {}":                               "go",
+		"n = int(input())\nmax_val = 0\nprint(n)":                      "python",
+		"const xs = [1, 2].map(x => x * 2);":                           "javascript",
+		"42":                                                           "",
+	}
+	for code, want := range tests {
+		if got := detectLanguage(code); got != want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
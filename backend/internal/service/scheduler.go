@@ -0,0 +1,129 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/repository"
+)
+
+// SM-2 scheduling constants
+const (
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+	minPassingGrade   = 3
+	maxGrade          = 5
+)
+
+// ReviewScheduler schedules problem reviews using the SM-2 algorithm
+type ReviewScheduler struct {
+	repo *repository.Repository
+	now  func() time.Time
+}
+
+// NewReviewScheduler creates a new review scheduler
+func NewReviewScheduler(repo *repository.Repository) *ReviewScheduler {
+	return &ReviewScheduler{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// GetDueReviews retrieves up to limit problems that are due for review,
+// optionally restricted to problems carrying the given tag
+func (rs *ReviewScheduler) GetDueReviews(limit int, tag string) ([]models.DueReview, error) {
+	return rs.repo.GetDueReviews(rs.now(), limit, tag)
+}
+
+// RecordReview grades a review of a problem from 0 (blackout) to 5 (perfect
+// recall) and schedules the next one
+func (rs *ReviewScheduler) RecordReview(problemID, grade int) (*models.ReviewState, error) {
+	if grade < 0 || grade > maxGrade {
+		return nil, models.NewError(models.ErrCodeValidation, "grade must be between 0 and %d", maxGrade)
+	}
+	if _, err := rs.repo.GetProblem(problemID); err != nil {
+		return nil, err
+	}
+
+	state, err := rs.repo.GetReviewState(problemID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &models.ReviewState{
+			ProblemID:  problemID,
+			EaseFactor: defaultEaseFactor,
+		}
+	}
+
+	now := rs.now()
+	nextReviewState(state, grade)
+	state.LastReviewedAt = models.CustomTime{Time: now}
+	state.DueAt = models.CustomTime{Time: now.AddDate(0, 0, state.IntervalDays)}
+
+	if err := rs.repo.SaveReviewState(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetForecast returns the number of reviews falling due on each of the next
+// days, starting today. Overdue reviews are counted as due today.
+func (rs *ReviewScheduler) GetForecast(days int, tag string) ([]models.ReviewForecastDay, error) {
+	if days <= 0 {
+		return nil, models.NewError(models.ErrCodeValidation, "days must be positive")
+	}
+
+	now := rs.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := today.AddDate(0, 0, days)
+
+	dueTimes, err := rs.repo.GetReviewDueTimes(end, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := make([]models.ReviewForecastDay, days)
+	for i := range forecast {
+		forecast[i].Date = today.AddDate(0, 0, i).Format("2006-01-02")
+	}
+	for _, due := range dueTimes {
+		due = due.In(now.Location())
+		day := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, now.Location())
+		index := 0
+		if day.After(today) {
+			index = int(math.Round(day.Sub(today).Hours() / 24))
+		}
+		if index < days {
+			forecast[index].Count++
+		}
+	}
+
+	return forecast, nil
+}
+
+// nextReviewState applies one SM-2 step to state for the given grade
+func nextReviewState(state *models.ReviewState, grade int) {
+	if grade < minPassingGrade {
+		state.Repetitions = 0
+		state.IntervalDays = 1
+		state.Lapses++
+	} else {
+		switch state.Repetitions {
+		case 0:
+			state.IntervalDays = 1
+		case 1:
+			state.IntervalDays = 6
+		default:
+			state.IntervalDays = int(math.Round(float64(state.IntervalDays) * state.EaseFactor))
+		}
+		state.Repetitions++
+	}
+
+	q := float64(maxGrade - grade)
+	state.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if state.EaseFactor < minEaseFactor {
+		state.EaseFactor = minEaseFactor
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestNextReviewState(t *testing.T) {
+	tests := []struct {
+		name  string
+		state models.ReviewState
+		grade int
+		want  models.ReviewState
+	}{
+		{
+			name:  "first review",
+			state: models.ReviewState{EaseFactor: 2.5},
+			grade: 5,
+			want:  models.ReviewState{EaseFactor: 2.6, IntervalDays: 1, Repetitions: 1},
+		},
+		{
+			name:  "second review",
+			state: models.ReviewState{EaseFactor: 2.6, IntervalDays: 1, Repetitions: 1},
+			grade: 4,
+			want:  models.ReviewState{EaseFactor: 2.6, IntervalDays: 6, Repetitions: 2},
+		},
+		{
+			name:  "interval grows by the ease factor",
+			state: models.ReviewState{EaseFactor: 2.6, IntervalDays: 6, Repetitions: 2},
+			grade: 3,
+			want:  models.ReviewState{EaseFactor: 2.46, IntervalDays: 16, Repetitions: 3},
+		},
+		{
+			name:  "lapse restarts the schedule",
+			state: models.ReviewState{EaseFactor: 2.46, IntervalDays: 16, Repetitions: 3, Lapses: 1},
+			grade: 2,
+			want:  models.ReviewState{EaseFactor: 2.14, IntervalDays: 1, Repetitions: 0, Lapses: 2},
+		},
+		{
+			name:  "blackout is floored at the minimum ease",
+			state: models.ReviewState{EaseFactor: 1.4, IntervalDays: 6, Repetitions: 2},
+			grade: 0,
+			want:  models.ReviewState{EaseFactor: minEaseFactor, IntervalDays: 1, Repetitions: 0, Lapses: 1},
+		},
+		{
+			name:  "minimum ease still grows the interval",
+			state: models.ReviewState{EaseFactor: minEaseFactor, IntervalDays: 10, Repetitions: 4},
+			grade: 3,
+			want:  models.ReviewState{EaseFactor: minEaseFactor, IntervalDays: 13, Repetitions: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			nextReviewState(&state, tt.grade)
+			if math.Abs(state.EaseFactor-tt.want.EaseFactor) > 1e-9 {
+				t.Errorf("ease factor = %v, want %v", state.EaseFactor, tt.want.EaseFactor)
+			}
+			state.EaseFactor = tt.want.EaseFactor
+			if state != tt.want {
+				t.Errorf("state = %+v, want %+v", state, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordReviewSchedulesFromNow(t *testing.T) {
+	svc := newTestService(t)
+	problem := &models.Problem{Name: "Two Sum", Platform: "LeetCode", Difficulty: "Easy"}
+	createProblems(t, svc, problem)
+
+	now := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	svc.reviews.now = func() time.Time { return now }
+	for _, grade := range []int{5, 4} {
+		if _, err := svc.RecordReview(problem.ID, grade); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err := svc.repo.GetReviewState(problem.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.IntervalDays != 6 || !state.LastReviewedAt.Equal(now) || !state.DueAt.Equal(now.AddDate(0, 0, 6)) {
+		t.Errorf("state = %+v, want due 6 days after %v", state, now)
+	}
+
+	var appErr *models.Error
+	if _, err := svc.RecordReview(problem.ID, 6); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("grade 6: err = %v, want VALIDATION", err)
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	svc := newTestService(t)
+	// Days split at midnight in the zone of the current time
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	now := time.Date(2024, 5, 1, 20, 0, 0, 0, zone)
+	svc.reviews.now = func() time.Time { return now }
+
+	dueTimes := []struct {
+		due time.Time
+		tag string
+	}{
+		{now.AddDate(0, 0, -10), "graph"},                     // overdue, counted today
+		{time.Date(2024, 5, 1, 23, 59, 0, 0, zone), "graph"},  // later today
+		{time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC), "dp"}, // 00:30 on May 2 in UTC+9
+		{time.Date(2024, 5, 3, 0, 0, 0, 0, zone), "dp"},       // start of the last day
+		{time.Date(2024, 5, 4, 0, 0, 0, 0, zone), "graph"},    // past the window
+		{time.Date(2024, 5, 2, 12, 0, 0, 0, zone), ""},        // untagged
+	}
+	for _, d := range dueTimes {
+		problem := &models.Problem{Name: "Due " + d.due.String(), Platform: "LeetCode", Difficulty: "Easy"}
+		if d.tag != "" {
+			problem.Tags = []models.Tag{{Name: d.tag}}
+		}
+		createProblems(t, svc, problem)
+		state := &models.ReviewState{ProblemID: problem.ID, EaseFactor: defaultEaseFactor, IntervalDays: 1,
+			DueAt: models.CustomTime{Time: d.due}, LastReviewedAt: models.CustomTime{Time: d.due.AddDate(0, 0, -1)}}
+		if err := svc.repo.SaveReviewState(state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		tag  string
+		want []models.ReviewForecastDay
+	}{
+		{"", []models.ReviewForecastDay{{Date: "2024-05-01", Count: 2}, {Date: "2024-05-02", Count: 2}, {Date: "2024-05-03", Count: 1}}},
+		{"graph", []models.ReviewForecastDay{{Date: "2024-05-01", Count: 2}, {Date: "2024-05-02", Count: 0}, {Date: "2024-05-03", Count: 0}}},
+		{"dp", []models.ReviewForecastDay{{Date: "2024-05-01", Count: 0}, {Date: "2024-05-02", Count: 1}, {Date: "2024-05-03", Count: 1}}},
+	}
+	for _, tt := range tests {
+		forecast, err := svc.GetReviewForecast(3, tt.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(forecast, tt.want) {
+			t.Errorf("forecast for tag %q = %v, want %v", tt.tag, forecast, tt.want)
+		}
+	}
+
+	if _, err := svc.GetReviewForecast(0, ""); err == nil {
+		t.Error("forecast of 0 days was accepted")
+	}
+}
@@ -1,41 +1,77 @@
 package service
 
 import (
+	"database/sql"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/platform"
 	"github.com/algorithmtracker/backend/internal/repository"
 )
 
 // Service handles business logic
 type Service struct {
-	repo *repository.Repository
+	db      *sql.DB
+	repo    *repository.Repository
+	reviews *ReviewScheduler
+	backup  backupTracker
 }
 
-// NewService creates a new service instance
-func NewService() *Service {
+// NewService creates a service that owns db and closes it in Close
+func NewService(db *sql.DB) *Service {
+	repo := repository.NewRepository(db)
 	return &Service{
-		repo: repository.NewRepository(),
+		db:      db,
+		repo:    repo,
+		reviews: NewReviewScheduler(repo),
 	}
 }
 
-// CreateProblem creates a new problem with validation
+// Close closes the database handle owned by the service
+func (s *Service) Close() error {
+	return s.db.Close()
+}
+
+// CreateProblem creates a new problem with validation. A link to a known
+// platform is normalized and fills in an empty platform. A problem with the
+// link of an existing one is rejected with a CONFLICT error. Without a
+// status the problem is taken to be solved.
 func (s *Service) CreateProblem(problem *models.Problem) error {
+	normalizeProblem(problem)
 	if err := s.validateProblem(problem); err != nil {
 		return err
 	}
+	if err := s.checkLinkDuplicate(problem); err != nil {
+		return err
+	}
 	return s.repo.CreateProblem(problem)
 }
 
-// UpdateProblem updates a problem with validation
+// UpdateProblem updates a problem with validation, rejecting a link already
+// used by another problem. A changed status must be a valid transition; an
+// empty status keeps the current one.
 func (s *Service) UpdateProblem(problem *models.Problem) error {
+	normalizeProblem(problem)
 	if err := s.validateProblem(problem); err != nil {
 		return err
 	}
+	current, err := s.repo.GetProblem(problem.ID)
+	if err != nil {
+		return err
+	}
+	if problem.Status == "" {
+		problem.Status = current.Status
+	} else if err := checkStatusTransition(current.Status, problem.Status); err != nil {
+		return err
+	}
+	if err := s.checkLinkDuplicate(problem); err != nil {
+		return err
+	}
 	return s.repo.UpdateProblem(problem)
 }
 
@@ -51,13 +87,44 @@ func (s *Service) GetProblem(id int) (*models.Problem, error) {
 
 // GetProblems retrieves problems with filtering
 func (s *Service) GetProblems(filter *models.ProblemFilter) ([]models.Problem, error) {
+	if err := normalizeFilter(filter); err != nil {
+		return nil, err
+	}
 	return s.repo.GetProblems(filter)
 }
 
+// GetProblemsPage retrieves one page of filtered problems
+func (s *Service) GetProblemsPage(filter *models.ProblemFilter) (*models.ProblemPage, error) {
+	if err := normalizeFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.repo.GetProblemsPage(filter)
+}
+
+// normalizeFilter maps the filter's platform to the canonical name problems
+// are stored under and folds the case of its status, rejecting an unknown
+// status rather than matching no problems
+func normalizeFilter(filter *models.ProblemFilter) error {
+	if filter == nil {
+		return nil
+	}
+	if filter.Platform != "" {
+		filter.Platform = platform.Canonical(filter.Platform)
+	}
+	if filter.Status == "" {
+		return nil
+	}
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	if !validStatus(filter.Status) {
+		return models.NewValidationError([]models.FieldError{{Field: "status", Message: statusMessage}})
+	}
+	return nil
+}
+
 // CreateTag creates a new tag
 func (s *Service) CreateTag(name string) (*models.Tag, error) {
 	if name == "" {
-		return nil, fmt.Errorf("tag name cannot be empty")
+		return nil, models.NewValidationError([]models.FieldError{{Field: "name", Message: "tag name cannot be empty"}})
 	}
 	return s.repo.CreateTag(name)
 }
@@ -72,17 +139,114 @@ func (s *Service) DeleteTag(id int) error {
 	return s.repo.DeleteTag(id)
 }
 
+// RenameTag renames a tag without touching its problem associations
+func (s *Service) RenameTag(id int, newName string) (*models.Tag, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return nil, models.NewValidationError([]models.FieldError{{Field: "name", Message: "tag name cannot be empty"}})
+	}
+	return s.repo.RenameTag(id, newName)
+}
+
+// MergeTags folds the source tags into the target tag
+func (s *Service) MergeTags(sourceIDs []int, targetID int) error {
+	if len(sourceIDs) == 0 {
+		return models.NewValidationError([]models.FieldError{{Field: "source_ids", Message: "at least one source tag is required"}})
+	}
+	return s.repo.MergeTags(sourceIDs, targetID)
+}
+
+// SetTagParent nests a tag under a parent tag, or makes it top-level when parentID is 0
+func (s *Service) SetTagParent(id, parentID int) (*models.Tag, error) {
+	return s.repo.SetTagParent(id, parentID)
+}
+
+// GetTagTree retrieves all tags arranged as a topic tree, with direct and
+// rolled-up problem counts
+func (s *Service) GetTagTree() ([]models.TagNode, error) {
+	tags, err := s.repo.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	direct, rollup, err := s.repo.GetTagCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Tag)
+	for _, tag := range tags {
+		children[tag.ParentID] = append(children[tag.ParentID], tag)
+	}
+
+	var build func(parentID int) []models.TagNode
+	build = func(parentID int) []models.TagNode {
+		nodes := []models.TagNode{}
+		for _, tag := range children[parentID] {
+			nodes = append(nodes, models.TagNode{
+				Tag:               tag,
+				ProblemCount:      direct[tag.ID],
+				TotalProblemCount: rollup[tag.ID],
+				Children:          build(tag.ID),
+			})
+		}
+		return nodes
+	}
+
+	return build(0), nil
+}
+
+// AddAttempt records a solve attempt for an existing problem
+func (s *Service) AddAttempt(attempt *models.Attempt) error {
+	if err := s.validateAttempt(attempt); err != nil {
+		return err
+	}
+	if attempt.AttemptedAt.IsZero() {
+		attempt.AttemptedAt = models.CustomTime{Time: time.Now()}
+	}
+
+	// Attempting a problem advances its status
+	return s.repo.CreateAttempt(attempt, func(status string) string {
+		return statusAfterAttempt(status, attempt.Verdict)
+	})
+}
+
+// GetAttempts retrieves the attempt history of a problem
+func (s *Service) GetAttempts(problemID int) ([]models.Attempt, error) {
+	return s.repo.GetAttempts(problemID)
+}
+
+// DeleteAttempt deletes an attempt
+func (s *Service) DeleteAttempt(id int) error {
+	return s.repo.DeleteAttempt(id)
+}
+
+// GetDueReviews retrieves problems due for review, optionally limited to a tag
+func (s *Service) GetDueReviews(limit int, tag string) ([]models.DueReview, error) {
+	return s.reviews.GetDueReviews(limit, tag)
+}
+
+// RecordReview records a graded review and reschedules the problem
+func (s *Service) RecordReview(problemID, grade int) (*models.ReviewState, error) {
+	return s.reviews.RecordReview(problemID, grade)
+}
+
+// GetReviewForecast retrieves the number of reviews due on each upcoming day
+func (s *Service) GetReviewForecast(days int, tag string) ([]models.ReviewForecastDay, error) {
+	return s.reviews.GetForecast(days, tag)
+}
+
 // GetStatistics retrieves statistics
 func (s *Service) GetStatistics() (*models.Statistics, error) {
 	return s.repo.GetStatistics()
 }
 
-// ExportToJSON exports problems to JSON file
+// ExportToJSON exports the whole database to a JSON archive file
 func (s *Service) ExportToJSON(filePath string) error {
-	problems, err := s.repo.GetProblems(nil)
+	archive, err := s.repo.ExportArchive()
 	if err != nil {
 		return err
 	}
+	archive.Settings = portableSettingValues(archive.Settings)
 
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -92,7 +256,8 @@ func (s *Service) ExportToJSON(filePath string) error {
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(problems)
+	encoder.SetEscapeHTML(false) // keep code readable
+	return encoder.Encode(archive)
 }
 
 // ExportToCSV exports problems to CSV file
@@ -112,7 +277,8 @@ func (s *Service) ExportToCSV(filePath string) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"ID", "Name", "Link", "Platform", "Difficulty", "SolveTime", "Tags", "Notes", "CreatedAt"}
+	header := []string{"ID", "Name", "Link", "Platform", "Difficulty", "SolveTime", "Tags", "Notes", "CreatedAt",
+		"CodeSnippet", "UpdatedAt", "Status"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -137,6 +303,9 @@ func (s *Service) ExportToCSV(filePath string) error {
 			tags,
 			p.Notes,
 			p.CreatedAt.Format("2006-01-02 15:04:05"),
+			p.CodeSnippet,
+			p.UpdatedAt.Format("2006-01-02 15:04:05"),
+			p.Status,
 		}
 		if err := writer.Write(record); err != nil {
 			return err
@@ -146,87 +315,75 @@ func (s *Service) ExportToCSV(filePath string) error {
 	return nil
 }
 
-// ImportFromJSON imports problems from JSON file
-func (s *Service) ImportFromJSON(filePath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	var problems []models.Problem
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&problems); err != nil {
-		return err
-	}
-
-	for _, problem := range problems {
-		problem.ID = 0 // Reset ID to create new records
-		if err := s.repo.CreateProblem(&problem); err != nil {
-			return err
+// normalizeProblem canonicalizes the platform name, so that "leetcode" and
+// "LC" are stored as LeetCode, and normalizes links of known platforms,
+// taking the platform from the link when none is given
+func normalizeProblem(problem *models.Problem) {
+	problem.Link = strings.TrimSpace(problem.Link)
+	if parsed, ok := platform.Parse(problem.Link); ok {
+		problem.Link = parsed.Link
+		if strings.TrimSpace(problem.Platform) == "" {
+			problem.Platform = parsed.Platform
 		}
 	}
-
-	return nil
+	problem.Platform = platform.Canonical(problem.Platform)
 }
 
-// BackupDatabase backs up the database file
-func (s *Service) BackupDatabase(dbPath, backupPath string) error {
-	sourceFile, err := os.Open(dbPath)
-	if err != nil {
-		return err
-	}
-	defer sourceFile.Close()
+// validateProblem validates problem data and fills in its difficulty level
+// and score from the difficulty scales
+func (s *Service) validateProblem(problem *models.Problem) error {
+	var fields []models.FieldError
 
-	destFile, err := os.Create(backupPath)
-	if err != nil {
-		return err
+	if problem.Name == "" {
+		fields = append(fields, models.FieldError{Field: "name", Message: "problem name is required"})
+	}
+	if problem.Platform == "" {
+		fields = append(fields, models.FieldError{Field: "platform", Message: "platform is required"})
 	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	return err
-}
 
-// RestoreDatabase restores the database from a backup
-func (s *Service) RestoreDatabase(dbPath, backupPath string) error {
-	sourceFile, err := os.Open(backupPath)
-	if err != nil {
-		return err
+	problem.Status = strings.ToLower(strings.TrimSpace(problem.Status))
+	if problem.Status != "" && !validStatus(problem.Status) {
+		fields = append(fields, models.FieldError{Field: "status", Message: statusMessage})
 	}
-	defer sourceFile.Close()
 
-	destFile, err := os.Create(dbPath)
+	// Resolve the difficulty on the platform's scale
+	field, err := s.newDifficultyScales().score(problem)
 	if err != nil {
 		return err
 	}
-	defer destFile.Close()
+	if field != nil {
+		fields = append(fields, *field)
+	}
 
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	if len(fields) > 0 {
+		return models.NewValidationError(fields)
+	}
+	return nil
 }
 
-// validateProblem validates problem data
-func (s *Service) validateProblem(problem *models.Problem) error {
-	if problem.Name == "" {
-		return fmt.Errorf("problem name is required")
-	}
-	if problem.Platform == "" {
-		return fmt.Errorf("platform is required")
+// validateAttempt validates attempt data
+func (s *Service) validateAttempt(attempt *models.Attempt) error {
+	var fields []models.FieldError
+
+	if attempt.ProblemID <= 0 {
+		fields = append(fields, models.FieldError{Field: "problem_id", Message: "problem id is required"})
 	}
-	if problem.Difficulty == "" {
-		return fmt.Errorf("difficulty is required")
+	if attempt.Duration < 0 {
+		fields = append(fields, models.FieldError{Field: "duration", Message: "duration cannot be negative"})
 	}
-	
-	// Validate difficulty level
-	validDifficulties := map[string]bool{
-		"Easy":   true,
-		"Medium": true,
-		"Hard":   true,
+
+	validVerdicts := map[string]bool{
+		models.VerdictAccepted:          true,
+		models.VerdictWrongAnswer:       true,
+		models.VerdictTimeLimitExceeded: true,
+		models.VerdictGaveUp:            true,
 	}
-	if !validDifficulties[problem.Difficulty] {
-		return fmt.Errorf("difficulty must be Easy, Medium, or Hard")
+	if !validVerdicts[attempt.Verdict] {
+		fields = append(fields, models.FieldError{Field: "verdict", Message: "verdict must be AC, WA, TLE, or GAVE_UP"})
 	}
 
+	if len(fields) > 0 {
+		return models.NewValidationError(fields)
+	}
 	return nil
 }
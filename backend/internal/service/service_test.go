@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestCreateProblemParsesLink(t *testing.T) {
+	svc := newTestService(t)
+
+	problem := &models.Problem{
+		Name:       "Copy of a Copy of a Copy",
+		Link:       " https://codeforces.com/contest/1520/problem/f ",
+		Difficulty: "Hard",
+	}
+	if err := svc.CreateProblem(problem); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.GetProblem(problem.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Platform != "Codeforces" || got.CanonicalID != "1520/F" ||
+		got.Link != "https://codeforces.com/problemset/problem/1520/F" {
+		t.Errorf("got platform %q, canonical ID %q, link %q", got.Platform, got.CanonicalID, got.Link)
+	}
+
+	// A platform spelled differently is canonicalized; one that disagrees
+	// with the link keeps the link but gets no canonical ID
+	got.Platform = "cf"
+	if err := svc.UpdateProblem(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Platform != "Codeforces" || got.CanonicalID != "1520/F" {
+		t.Errorf("after update: platform %q, canonical ID %q", got.Platform, got.CanonicalID)
+	}
+
+	got.Platform = "AtCoder"
+	if err := svc.UpdateProblem(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CanonicalID != "" {
+		t.Errorf("canonical ID = %q for a link of another platform", got.CanonicalID)
+	}
+
+	// Without a platform or a recognized link, the platform is still required
+	err = svc.CreateProblem(&models.Problem{Name: "Mystery", Link: "https://example.com/p/1", Difficulty: "Easy"})
+	if err == nil {
+		t.Error("created a problem without a platform")
+	}
+}
+
+func TestPlatformFilterAcceptsAliases(t *testing.T) {
+	svc := newTestService(t)
+	createProblems(t, svc,
+		&models.Problem{Name: "Two Sum", Platform: "leetcode", Difficulty: "Easy"},
+		&models.Problem{Name: "Watermelon", Platform: "Codeforces", Difficulty: "Easy"})
+
+	for _, name := range []string{"LeetCode", "leetcode", "LC", " lc "} {
+		problems, err := svc.GetProblems(&models.ProblemFilter{Platform: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 1 || problems[0].Name != "Two Sum" {
+			t.Errorf("platform filter %q = %+v, want only Two Sum", name, problems)
+		}
+	}
+
+	page, err := svc.GetProblemsPage(&models.ProblemFilter{Platform: "cf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Problems[0].Name != "Watermelon" {
+		t.Errorf("paged platform filter %q = %+v, want only Watermelon", "cf", page)
+	}
+}
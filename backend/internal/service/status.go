@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// statusMessage describes the valid statuses in validation errors
+const statusMessage = "status must be todo, attempted, solved, mastered, or revisit"
+
+// statusTransitions lists the statuses each status can move to. Problems
+// move forward through todo, attempted, solved and mastered; any problem
+// that was attempted can be marked for a revisit, and an attempted one can
+// go back to the backlog.
+var statusTransitions = map[string][]string{
+	models.StatusTodo:      {models.StatusAttempted, models.StatusSolved},
+	models.StatusAttempted: {models.StatusTodo, models.StatusSolved, models.StatusRevisit},
+	models.StatusSolved:    {models.StatusMastered, models.StatusRevisit},
+	models.StatusMastered:  {models.StatusRevisit},
+	models.StatusRevisit:   {models.StatusAttempted, models.StatusSolved, models.StatusMastered},
+}
+
+// validStatus reports whether status is a known problem status
+func validStatus(status string) bool {
+	return slices.Contains(models.ProblemStatuses, status)
+}
+
+// checkStatusTransition returns a validation error unless a problem may move
+// from status from to status to. Staying in a status is always allowed.
+func checkStatusTransition(from, to string) error {
+	if from == to || slices.Contains(statusTransitions[from], to) {
+		return nil
+	}
+	return models.NewValidationError([]models.FieldError{{
+		Field:   "status",
+		Message: fmt.Sprintf("cannot change status from %s to %s", from, to),
+	}})
+}
+
+// statusAfterAttempt returns the status a problem moves to when an attempt
+// with the given verdict is recorded: an accepted attempt solves a problem
+// that is not solved yet, and any attempt starts a problem still to do
+func statusAfterAttempt(status, verdict string) string {
+	switch {
+	case verdict == models.VerdictAccepted &&
+		(status == models.StatusTodo || status == models.StatusAttempted || status == models.StatusRevisit):
+		return models.StatusSolved
+	case status == models.StatusTodo:
+		return models.StatusAttempted
+	}
+	return status
+}
+
+// SetProblemStatus moves a problem to a new status, recording the transition,
+// and returns the updated problem
+func (s *Service) SetProblemStatus(problemID int, status string) (*models.Problem, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if !validStatus(status) {
+		return nil, models.NewValidationError([]models.FieldError{{Field: "status", Message: statusMessage}})
+	}
+
+	problem, err := s.repo.GetProblem(problemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatusTransition(problem.Status, status); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetProblemStatus(problemID, status, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetProblem(problemID)
+}
+
+// GetStatusHistory retrieves the status transitions of a problem, oldest
+// first
+func (s *Service) GetStatusHistory(problemID int) ([]models.StatusChange, error) {
+	if _, err := s.repo.GetProblem(problemID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStatusHistory(problemID)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestStatusWorkflow(t *testing.T) {
+	svc := newTestService(t)
+	backlog := &models.Problem{Name: "Backlog", Platform: "LeetCode", Difficulty: "Hard", Status: models.StatusTodo}
+	solved := &models.Problem{Name: "Solved", Platform: "LeetCode", Difficulty: "Easy"}
+	createProblems(t, svc, backlog, solved)
+	if solved.Status != models.StatusSolved {
+		t.Errorf("status without one given = %q, want solved", solved.Status)
+	}
+
+	// Attempts advance a problem from the backlog to solved
+	for _, verdict := range []string{models.VerdictWrongAnswer, models.VerdictWrongAnswer, models.VerdictAccepted} {
+		if err := svc.AddAttempt(&models.Attempt{ProblemID: backlog.ID, Verdict: verdict}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	problem, err := svc.SetProblemStatus(backlog.ID, "Mastered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != models.StatusMastered {
+		t.Errorf("status = %q, want mastered", problem.Status)
+	}
+
+	var appErr *models.Error
+	if _, err := svc.SetProblemStatus(backlog.ID, models.StatusTodo); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("mastered to todo: err = %v, want VALIDATION", err)
+	}
+	if _, err := svc.SetProblemStatus(backlog.ID, "forgotten"); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("unknown status: err = %v, want VALIDATION", err)
+	}
+
+	history, err := svc.GetStatusHistory(backlog.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"->todo", "todo->attempted", "attempted->solved", "solved->mastered"}
+	if len(history) != len(want) {
+		t.Fatalf("history = %+v, want %v", history, want)
+	}
+	for i, change := range history {
+		if got := change.FromStatus + "->" + change.ToStatus; got != want[i] {
+			t.Errorf("change %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	// An update without a status keeps it; one with a status moves it
+	solved.Status = ""
+	if err := svc.UpdateProblem(solved); err != nil || solved.Status != models.StatusSolved {
+		t.Errorf("update without status: status %q, err %v", solved.Status, err)
+	}
+	solved.Status = models.StatusRevisit
+	if err := svc.UpdateProblem(solved); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := svc.GetProblems(&models.ProblemFilter{Status: models.StatusRevisit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].ID != solved.ID {
+		t.Errorf("revisit filter = %+v, want only %q", problems, solved.Name)
+	}
+
+	// The status filter folds case and rejects unknown statuses
+	if problems, err := svc.GetProblems(&models.ProblemFilter{Status: " Mastered"}); err != nil || len(problems) != 1 {
+		t.Errorf("mastered filter = %d problems, %v; want 1", len(problems), err)
+	}
+	if _, err := svc.GetProblemsPage(&models.ProblemFilter{Status: "solvd"}); !errors.As(err, &appErr) || appErr.Code != models.ErrCodeValidation {
+		t.Errorf("unknown status filter: err = %v, want VALIDATION", err)
+	}
+
+	// The repository defaults a missing status to solved as well
+	direct := &models.Problem{Name: "Direct", Platform: "LeetCode", Difficulty: "Easy"}
+	if err := svc.repo.CreateProblem(direct); err != nil {
+		t.Fatal(err)
+	}
+	if history, err := svc.GetStatusHistory(direct.ID); err != nil || len(history) != 1 || history[0].ToStatus != models.StatusSolved {
+		t.Errorf("history of a problem created without status = %+v, %v", history, err)
+	}
+
+	stats, err := svc.GetStatistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStats := map[string]int{"todo": 0, "attempted": 0, "solved": 1, "mastered": 1, "revisit": 1}
+	for status, count := range wantStats {
+		if stats.ByStatus[status] != count {
+			t.Errorf("by status = %v, want %v", stats.ByStatus, wantStats)
+			break
+		}
+	}
+}
@@ -92,6 +92,23 @@ extern char* AddProblem(char* jsonData);
 //
 extern char* UpdateProblem(char* jsonData);
 
+// FindDuplicates scans all problems for clusters of likely duplicates
+//
+extern char* FindDuplicates();
+
+// MergeProblems merges the problems listed in a JSON array of IDs into the
+// kept problem, uniting their tags and notes
+//
+extern char* MergeProblems(int keepID, char* dropIDs);
+
+// SetProblemStatus moves a problem to a new status (todo, attempted, solved, mastered or revisit)
+//
+extern char* SetProblemStatus(int problemID, char* status);
+
+// GetStatusHistory retrieves the status transitions of a problem
+//
+extern char* GetStatusHistory(int problemID);
+
 // DeleteProblem deletes a problem by ID
 //
 extern char* DeleteProblem(int id);
@@ -104,6 +121,10 @@ extern char* GetProblem(int id);
 //
 extern char* GetProblems(char* filterJSON);
 
+// GetProblemsPage retrieves one page of problems with the total match count
+//
+extern char* GetProblemsPage(char* filterJSON);
+
 // AddTag adds a new tag
 //
 extern char* AddTag(char* name);
@@ -116,22 +137,126 @@ extern char* GetTags();
 //
 extern char* DeleteTag(int id);
 
+// RenameTag renames a tag
+//
+extern char* RenameTag(int id, char* newName);
+
+// MergeTags merges the tags listed in a JSON array of IDs into the target tag
+//
+extern char* MergeTags(char* sourceIDs, int targetID);
+
+// SetTagParent nests a tag under a parent tag (0 for top-level)
+//
+extern char* SetTagParent(int id, int parentID);
+
+// GetTagTree retrieves all tags arranged as a topic tree
+//
+extern char* GetTagTree();
+
+// AddAttempt records a solve attempt for a problem
+//
+extern char* AddAttempt(char* jsonData);
+
+// GetAttempts retrieves the attempt history of a problem
+//
+extern char* GetAttempts(int problemID);
+
+// DeleteAttempt deletes an attempt by ID
+//
+extern char* DeleteAttempt(int id);
+
+// GetDueReviews retrieves problems due for review
+//
+extern char* GetDueReviews(int limit);
+
+// GetDueReviewsForTag retrieves problems due for review carrying the given tag
+//
+extern char* GetDueReviewsForTag(int limit, char* tag);
+
+// RecordReview records a graded review (0-5) of a problem
+//
+extern char* RecordReview(int problemID, int grade);
+
+// GetReviewForecast retrieves the number of reviews due on each of the next days
+//
+extern char* GetReviewForecast(int days, char* tag);
+
 // GetStatistics retrieves problem statistics
 //
 extern char* GetStatistics();
 
-// ExportData exports data to file
+// GetActivityStatistics retrieves solves per day, week or month, a daily heatmap,
+// streaks and rolling counts
+//
+extern char* GetActivityStatistics(char* optionsJSON);
+
+// GetTimezone returns the timezone activity statistics use, empty for the system zone
+//
+extern char* GetTimezone();
+
+// SetTimezone sets the IANA timezone activity statistics use, empty for the system zone
+//
+extern char* SetTimezone(char* name);
+
+// GetDifficultyScales retrieves the per-platform difficulty scales
+//
+extern char* GetDifficultyScales();
+
+// UpdateDifficultyScale replaces a platform's difficulty scale and rescores its problems
+//
+extern char* UpdateDifficultyScale(char* jsonData);
+
+// ExportData exports data to file, or to a directory for the markdown and html notebook formats.
+// The apkg and anki_text formats write an Anki deck.
 //
 extern char* ExportData(char* format, char* filePath);
 
-// ImportData imports data from file
+// ExportNotebook writes a Markdown or HTML solution notebook into a directory
+//
+extern char* ExportNotebook(char* dirPath, char* optionsJSON);
+
+// ExportAnki writes an Anki deck of the problems matching the options' filter
+//
+extern char* ExportAnki(char* filePath, char* optionsJSON);
+
+// ImportData imports data from file, skipping problems that already exist
 //
 extern char* ImportData(char* format, char* filePath);
 
+// ImportCSV imports problems from a CSV file with a column mapping and
+// duplicate strategy, or only reports the outcome when dry_run is set
+//
+extern char* ImportCSV(char* filePath, char* optionsJSON);
+
+// ImportJSON imports problems from a JSON file with a duplicate strategy, or
+// only reports the outcome when dry_run is set
+//
+extern char* ImportJSON(char* filePath, char* optionsJSON);
+
 // BackupDatabase backs up the database
 //
 extern char* BackupDatabase(char* dbPath, char* backupPath);
 
+// GetBackupProgress reports the progress of the running or most recent backup
+//
+extern char* GetBackupProgress();
+
+// GetBackupSettings returns the automatic backup policy
+//
+extern char* GetBackupSettings();
+
+// UpdateBackupSettings replaces the automatic backup policy
+//
+extern char* UpdateBackupSettings(char* jsonData);
+
+// ListBackups lists the scheduled backups, newest first
+//
+extern char* ListBackups();
+
+// PruneBackups deletes the scheduled backups the retention policy does not keep
+//
+extern char* PruneBackups();
+
 // RestoreDatabase restores the database from backup
 //
 extern char* RestoreDatabase(char* dbPath, char* backupPath);
@@ -4,211 +4,673 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/algorithmtracker/backend/internal/database"
 	"github.com/algorithmtracker/backend/internal/models"
 	"github.com/algorithmtracker/backend/internal/service"
 )
 
-var svc *service.Service
-
 // InitDB initializes the database
-func InitDB(dbPath string) string {
-	if err := database.Initialize(dbPath); err != nil {
-		return errorResponse(err.Error())
+func InitDB(dbPath string) (result string) {
+	defer recoverPanic("InitDB", &result)
+
+	if err := initialize(dbPath); err != nil {
+		return errorResponse(err)
 	}
-	
-	svc = service.NewService()
+
 	return successResponse("Database initialized successfully", nil)
 }
 
 // AddProblem adds a new problem
 func AddProblem(jsonData string) string {
-	var problem models.Problem
-	if err := json.Unmarshal([]byte(jsonData), &problem); err != nil {
-		return errorResponse(fmt.Sprintf("Invalid JSON: %v", err))
-	}
+	return withService("AddProblem", func(svc *service.Service) string {
+		var problem models.Problem
+		if err := json.Unmarshal([]byte(jsonData), &problem); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
 
-	if err := svc.CreateProblem(&problem); err != nil {
-		return errorResponse(err.Error())
-	}
+		if err := svc.CreateProblem(&problem); err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Problem added successfully", problem)
+		return successResponseWithWarnings("Problem added successfully", problem, similarProblemWarnings(svc, &problem))
+	})
 }
 
 // UpdateProblem updates an existing problem
 func UpdateProblem(jsonData string) string {
-	var problem models.Problem
-	if err := json.Unmarshal([]byte(jsonData), &problem); err != nil {
-		return errorResponse(fmt.Sprintf("Invalid JSON: %v", err))
-	}
+	return withService("UpdateProblem", func(svc *service.Service) string {
+		var problem models.Problem
+		if err := json.Unmarshal([]byte(jsonData), &problem); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		if err := svc.UpdateProblem(&problem); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponseWithWarnings("Problem updated successfully", problem, similarProblemWarnings(svc, &problem))
+	})
+}
+
+// FindDuplicates scans all problems for clusters of likely duplicates
+func FindDuplicates() string {
+	return withService("FindDuplicates", func(svc *service.Service) string {
+		clusters, err := svc.FindDuplicates()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Duplicates retrieved successfully", clusters)
+	})
+}
+
+// MergeProblems merges the problems listed in a JSON array of IDs into the
+// kept problem, uniting their tags and notes
+func MergeProblems(keepID int, dropIDsJSON string) string {
+	return withService("MergeProblems", func(svc *service.Service) string {
+		var dropIDs []int
+		if err := json.Unmarshal([]byte(dropIDsJSON), &dropIDs); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		problem, err := svc.MergeProblems(keepID, dropIDs)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Problems merged successfully", problem)
+	})
+}
+
+// SetProblemStatus moves a problem to a new status (todo, attempted, solved, mastered or revisit)
+func SetProblemStatus(problemID int, status string) string {
+	return withService("SetProblemStatus", func(svc *service.Service) string {
+		problem, err := svc.SetProblemStatus(problemID, status)
+		if err != nil {
+			return errorResponse(err)
+		}
 
-	if err := svc.UpdateProblem(&problem); err != nil {
-		return errorResponse(err.Error())
+		return successResponse("Problem status updated successfully", problem)
+	})
+}
+
+// GetStatusHistory retrieves the status transitions of a problem
+func GetStatusHistory(problemID int) string {
+	return withService("GetStatusHistory", func(svc *service.Service) string {
+		history, err := svc.GetStatusHistory(problemID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Status history retrieved successfully", history)
+	})
+}
+
+// similarProblemWarnings returns a "did you mean" warning for each problem
+// similar to a saved one. The problem is already saved, so a failed lookup
+// only loses the warnings.
+func similarProblemWarnings(svc *service.Service, problem *models.Problem) []models.Warning {
+	matches, err := svc.SimilarProblems(problem)
+	if err != nil {
+		logError("SimilarProblems", err)
+		return nil
 	}
 
-	return successResponse("Problem updated successfully", problem)
+	warnings := make([]models.Warning, len(matches))
+	for i, match := range matches {
+		warnings[i] = models.Warning{
+			Code:    models.WarningSimilarProblem,
+			Message: fmt.Sprintf("Did you mean #%d %q?", match.Problem.ID, match.Problem.Name),
+			Data:    match,
+		}
+	}
+	return warnings
 }
 
 // DeleteProblem deletes a problem by ID
 func DeleteProblem(id int) string {
-	if err := svc.DeleteProblem(id); err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("DeleteProblem", func(svc *service.Service) string {
+		if err := svc.DeleteProblem(id); err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Problem deleted successfully", nil)
+		return successResponse("Problem deleted successfully", nil)
+	})
 }
 
 // GetProblem retrieves a problem by ID
 func GetProblem(id int) string {
-	problem, err := svc.GetProblem(id)
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("GetProblem", func(svc *service.Service) string {
+		problem, err := svc.GetProblem(id)
+		if err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Problem retrieved successfully", problem)
+		return successResponse("Problem retrieved successfully", problem)
+	})
 }
 
 // GetProblems retrieves problems with optional filtering
 func GetProblems(filterJSON string) string {
-	var filter *models.ProblemFilter
-	
-	if filterJSON != "" && filterJSON != "{}" {
-		filter = &models.ProblemFilter{}
-		if err := json.Unmarshal([]byte(filterJSON), filter); err != nil {
-			return errorResponse(fmt.Sprintf("Invalid filter JSON: %v", err))
+	return withService("GetProblems", func(svc *service.Service) string {
+		var filter *models.ProblemFilter
+
+		if filterJSON != "" && filterJSON != "{}" {
+			filter = &models.ProblemFilter{}
+			if err := json.Unmarshal([]byte(filterJSON), filter); err != nil {
+				return errorResponse(invalidInput("Invalid filter JSON: %v", err))
+			}
 		}
-	}
 
-	problems, err := svc.GetProblems(filter)
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+		problems, err := svc.GetProblems(filter)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Problems retrieved successfully", problems)
+	})
+}
+
+// GetProblemsPage retrieves one page of problems with the total match count
+// and a cursor for the next page
+func GetProblemsPage(filterJSON string) string {
+	return withService("GetProblemsPage", func(svc *service.Service) string {
+		var filter *models.ProblemFilter
+
+		if filterJSON != "" && filterJSON != "{}" {
+			filter = &models.ProblemFilter{}
+			if err := json.Unmarshal([]byte(filterJSON), filter); err != nil {
+				return errorResponse(invalidInput("Invalid filter JSON: %v", err))
+			}
+		}
+
+		page, err := svc.GetProblemsPage(filter)
+		if err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Problems retrieved successfully", problems)
+		return successResponse("Problems retrieved successfully", page)
+	})
 }
 
 // AddTag adds a new tag
 func AddTag(name string) string {
-	tag, err := svc.CreateTag(name)
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("AddTag", func(svc *service.Service) string {
+		tag, err := svc.CreateTag(name)
+		if err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Tag added successfully", tag)
+		return successResponse("Tag added successfully", tag)
+	})
 }
 
 // GetTags retrieves all tags
 func GetTags() string {
-	tags, err := svc.GetTags()
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("GetTags", func(svc *service.Service) string {
+		tags, err := svc.GetTags()
+		if err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Tags retrieved successfully", tags)
+		return successResponse("Tags retrieved successfully", tags)
+	})
 }
 
 // DeleteTag deletes a tag by ID
 func DeleteTag(id int) string {
-	if err := svc.DeleteTag(id); err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("DeleteTag", func(svc *service.Service) string {
+		if err := svc.DeleteTag(id); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Tag deleted successfully", nil)
+	})
+}
+
+// RenameTag renames a tag
+func RenameTag(id int, newName string) string {
+	return withService("RenameTag", func(svc *service.Service) string {
+		tag, err := svc.RenameTag(id, newName)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Tag renamed successfully", tag)
+	})
+}
+
+// MergeTags merges the tags listed in a JSON array of IDs into the target tag
+func MergeTags(sourceIDsJSON string, targetID int) string {
+	return withService("MergeTags", func(svc *service.Service) string {
+		var sourceIDs []int
+		if err := json.Unmarshal([]byte(sourceIDsJSON), &sourceIDs); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		if err := svc.MergeTags(sourceIDs, targetID); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Tags merged successfully", nil)
+	})
+}
+
+// SetTagParent nests a tag under a parent tag (0 for top-level)
+func SetTagParent(id, parentID int) string {
+	return withService("SetTagParent", func(svc *service.Service) string {
+		tag, err := svc.SetTagParent(id, parentID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Tag parent updated successfully", tag)
+	})
+}
 
-	return successResponse("Tag deleted successfully", nil)
+// GetTagTree retrieves all tags arranged as a topic tree
+func GetTagTree() string {
+	return withService("GetTagTree", func(svc *service.Service) string {
+		tree, err := svc.GetTagTree()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Tag tree retrieved successfully", tree)
+	})
+}
+
+// AddAttempt records a solve attempt for a problem
+func AddAttempt(jsonData string) string {
+	return withService("AddAttempt", func(svc *service.Service) string {
+		var attempt models.Attempt
+		if err := json.Unmarshal([]byte(jsonData), &attempt); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		if err := svc.AddAttempt(&attempt); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Attempt added successfully", attempt)
+	})
+}
+
+// GetAttempts retrieves the attempt history of a problem
+func GetAttempts(problemID int) string {
+	return withService("GetAttempts", func(svc *service.Service) string {
+		attempts, err := svc.GetAttempts(problemID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Attempts retrieved successfully", attempts)
+	})
+}
+
+// DeleteAttempt deletes an attempt by ID
+func DeleteAttempt(id int) string {
+	return withService("DeleteAttempt", func(svc *service.Service) string {
+		if err := svc.DeleteAttempt(id); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Attempt deleted successfully", nil)
+	})
+}
+
+// GetDueReviews retrieves problems due for review
+func GetDueReviews(limit int) string {
+	return GetDueReviewsForTag(limit, "")
+}
+
+// GetDueReviewsForTag retrieves problems due for review carrying the given tag
+func GetDueReviewsForTag(limit int, tag string) string {
+	return withService("GetDueReviewsForTag", func(svc *service.Service) string {
+		reviews, err := svc.GetDueReviews(limit, tag)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Due reviews retrieved successfully", reviews)
+	})
+}
+
+// RecordReview records a graded review (0-5) of a problem
+func RecordReview(problemID, grade int) string {
+	return withService("RecordReview", func(svc *service.Service) string {
+		state, err := svc.RecordReview(problemID, grade)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Review recorded successfully", state)
+	})
+}
+
+// GetReviewForecast retrieves the number of reviews due on each of the next days
+func GetReviewForecast(days int, tag string) string {
+	return withService("GetReviewForecast", func(svc *service.Service) string {
+		forecast, err := svc.GetReviewForecast(days, tag)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Review forecast retrieved successfully", forecast)
+	})
 }
 
 // GetStatistics retrieves problem statistics
 func GetStatistics() string {
-	stats, err := svc.GetStatistics()
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("GetStatistics", func(svc *service.Service) string {
+		stats, err := svc.GetStatistics()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Statistics retrieved successfully", stats)
+	})
+}
+
+// GetActivityStatistics retrieves solves per day, week or month, a daily heatmap,
+// streaks and rolling counts
+func GetActivityStatistics(optionsJSON string) string {
+	return withService("GetActivityStatistics", func(svc *service.Service) string {
+		var options models.ActivityOptions
+		if optionsJSON != "" {
+			if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+				return errorResponse(invalidInput("Invalid JSON: %v", err))
+			}
+		}
+
+		stats, err := svc.GetActivityStatistics(&options)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Activity statistics retrieved successfully", stats)
+	})
+}
+
+// GetTimezone returns the timezone activity statistics use, empty for the system zone
+func GetTimezone() string {
+	return withService("GetTimezone", func(svc *service.Service) string {
+		name, err := svc.GetTimezone()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Timezone retrieved successfully", name)
+	})
+}
+
+// SetTimezone sets the IANA timezone activity statistics use, empty for the system zone
+func SetTimezone(name string) string {
+	return withService("SetTimezone", func(svc *service.Service) string {
+		if err := svc.SetTimezone(name); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Timezone updated successfully", nil)
+	})
+}
+
+// GetDifficultyScales retrieves the per-platform difficulty scales
+func GetDifficultyScales() string {
+	return withService("GetDifficultyScales", func(svc *service.Service) string {
+		scales, err := svc.GetDifficultyScales()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Difficulty scales retrieved successfully", scales)
+	})
+}
+
+// UpdateDifficultyScale replaces a platform's difficulty scale and rescores its problems
+func UpdateDifficultyScale(jsonData string) string {
+	return withService("UpdateDifficultyScale", func(svc *service.Service) string {
+		var scale models.DifficultyScale
+		if err := json.Unmarshal([]byte(jsonData), &scale); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		if err := svc.UpdateDifficultyScale(&scale); err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Statistics retrieved successfully", stats)
+		return successResponse("Difficulty scale updated successfully", scale)
+	})
 }
 
-// ExportData exports data to file
+// ExportData exports data to file, or to a directory for the markdown and html notebook formats.
+// The apkg and anki_text formats write an Anki deck.
 func ExportData(format, filePath string) string {
-	var err error
-	
-	switch format {
-	case "json":
-		err = svc.ExportToJSON(filePath)
-	case "csv":
-		err = svc.ExportToCSV(filePath)
-	default:
-		return errorResponse("Invalid format. Use 'json' or 'csv'")
-	}
+	return withService("ExportData", func(svc *service.Service) string {
+		var err error
+
+		switch format {
+		case "json":
+			err = svc.ExportToJSON(filePath)
+		case "csv":
+			err = svc.ExportToCSV(filePath)
+		case models.NotebookMarkdown, models.NotebookHTML:
+			err = svc.ExportNotebook(filePath, &models.NotebookOptions{Format: format})
+		case models.AnkiPackage, models.AnkiText:
+			err = svc.ExportAnki(filePath, &models.AnkiOptions{Format: format})
+		default:
+			return errorResponse(invalidInput("Invalid format. Use 'json', 'csv', 'markdown', 'html', 'apkg' or 'anki_text'"))
+		}
 
-	if err != nil {
-		return errorResponse(err.Error())
-	}
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Data exported successfully", nil)
+	})
+}
+
+// ExportNotebook writes a Markdown or HTML solution notebook into a directory
+func ExportNotebook(dirPath, optionsJSON string) string {
+	return withService("ExportNotebook", func(svc *service.Service) string {
+		var options models.NotebookOptions
+		if optionsJSON != "" {
+			if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+				return errorResponse(invalidInput("Invalid JSON: %v", err))
+			}
+		}
+
+		if err := svc.ExportNotebook(dirPath, &options); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Notebook exported successfully", nil)
+	})
+}
+
+// ExportAnki writes an Anki deck of the problems matching the options' filter
+func ExportAnki(filePath, optionsJSON string) string {
+	return withService("ExportAnki", func(svc *service.Service) string {
+		var options models.AnkiOptions
+		if optionsJSON != "" {
+			if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+				return errorResponse(invalidInput("Invalid JSON: %v", err))
+			}
+		}
+
+		if err := svc.ExportAnki(filePath, &options); err != nil {
+			return errorResponse(err)
+		}
 
-	return successResponse("Data exported successfully", nil)
+		return successResponse("Anki deck exported successfully", nil)
+	})
 }
 
-// ImportData imports data from file
+// ImportData imports data from file, skipping problems that already exist
 func ImportData(format, filePath string) string {
+	return withService("ImportData", func(svc *service.Service) string {
+		return importFile(svc, format, filePath, nil)
+	})
+}
+
+// ImportCSV imports problems from a CSV file with a column mapping and
+// duplicate strategy, or only reports the outcome when dry_run is set
+func ImportCSV(filePath, optionsJSON string) string {
+	return importWithOptions("ImportCSV", "csv", filePath, optionsJSON)
+}
+
+// ImportJSON imports problems from a JSON file with a duplicate strategy, or
+// only reports the outcome when dry_run is set
+func ImportJSON(filePath, optionsJSON string) string {
+	return importWithOptions("ImportJSON", "json", filePath, optionsJSON)
+}
+
+// importWithOptions decodes import options and runs the import
+func importWithOptions(op, format, filePath, optionsJSON string) string {
+	return withService(op, func(svc *service.Service) string {
+		var options models.ImportOptions
+		if optionsJSON != "" {
+			if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+				return errorResponse(invalidInput("Invalid JSON: %v", err))
+			}
+		}
+
+		return importFile(svc, format, filePath, &options)
+	})
+}
+
+// importFile runs an import and reports the per-row result
+func importFile(svc *service.Service, format, filePath string, options *models.ImportOptions) string {
+	var result *models.ImportResult
 	var err error
-	
+
 	switch format {
 	case "json":
-		err = svc.ImportFromJSON(filePath)
+		result, err = svc.ImportFromJSON(filePath, options)
+	case "csv":
+		result, err = svc.ImportFromCSV(filePath, options)
 	default:
-		return errorResponse("Only JSON import is supported")
+		return errorResponse(invalidInput("Invalid format. Use 'json' or 'csv'"))
 	}
 
 	if err != nil {
-		return errorResponse(err.Error())
+		return errorResponse(err)
 	}
 
-	return successResponse("Data imported successfully", nil)
+	if result.DryRun {
+		return successResponse("Import checked successfully, nothing was written", result)
+	}
+	return successResponse("Data imported successfully", result)
 }
 
 // BackupDatabase backs up the database
 func BackupDatabase(dbPath, backupPath string) string {
-	if err := svc.BackupDatabase(dbPath, backupPath); err != nil {
-		return errorResponse(err.Error())
-	}
+	return withService("BackupDatabase", func(svc *service.Service) string {
+		if err := svc.BackupDatabase(dbPath, backupPath); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Database backed up successfully", svc.GetBackupProgress())
+	})
+}
 
-	return successResponse("Database backed up successfully", nil)
+// GetBackupProgress reports the progress of the running or most recent backup
+func GetBackupProgress() string {
+	return withService("GetBackupProgress", func(svc *service.Service) string {
+		return successResponse("Backup progress retrieved successfully", svc.GetBackupProgress())
+	})
+}
+
+// GetBackupSettings returns the automatic backup policy
+func GetBackupSettings() string {
+	return withService("GetBackupSettings", func(svc *service.Service) string {
+		settings, err := svc.GetBackupSettings()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Backup settings retrieved successfully", settings)
+	})
+}
+
+// UpdateBackupSettings replaces the automatic backup policy
+func UpdateBackupSettings(jsonData string) string {
+	return withService("UpdateBackupSettings", func(svc *service.Service) string {
+		var settings models.BackupSettings
+		if err := json.Unmarshal([]byte(jsonData), &settings); err != nil {
+			return errorResponse(invalidInput("Invalid JSON: %v", err))
+		}
+
+		if err := svc.UpdateBackupSettings(&settings); err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Backup settings updated successfully", settings)
+	})
+}
+
+// ListBackups lists the scheduled backups, newest first
+func ListBackups() string {
+	return withService("ListBackups", func(svc *service.Service) string {
+		backups, err := svc.ListBackups()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Backups retrieved successfully", backups)
+	})
+}
+
+// PruneBackups deletes the scheduled backups the retention policy does not keep
+func PruneBackups() string {
+	return withService("PruneBackups", func(svc *service.Service) string {
+		removed, err := svc.PruneBackups()
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return successResponse("Backups pruned successfully", removed)
+	})
 }
 
 // RestoreDatabase restores the database from backup
-func RestoreDatabase(dbPath, backupPath string) string {
-	// Close current database connection
-	database.Close()
-	
-	if err := svc.RestoreDatabase(dbPath, backupPath); err != nil {
-		return errorResponse(err.Error())
-	}
+func RestoreDatabase(dbPath, backupPath string) (result string) {
+	defer recoverPanic("RestoreDatabase", &result)
 
-	// Reinitialize database
-	if err := database.Initialize(dbPath); err != nil {
-		return errorResponse(err.Error())
+	restored, err := restore(dbPath, backupPath)
+	if err != nil {
+		return errorResponse(err)
 	}
 
-	return successResponse("Database restored successfully", nil)
+	return successResponse("Database restored successfully", restored)
 }
 
 // Helper functions
 
 func successResponse(message string, data interface{}) string {
+	return successResponseWithWarnings(message, data, nil)
+}
+
+func successResponseWithWarnings(message string, data interface{}, warnings []models.Warning) string {
 	response := models.Response{
-		Success: true,
-		Message: message,
-		Data:    data,
+		Success:  true,
+		Message:  message,
+		Data:     data,
+		Warnings: warnings,
 	}
-	
+
 	jsonData, _ := json.Marshal(response)
 	return string(jsonData)
 }
 
-func errorResponse(message string) string {
+func errorResponse(err error) string {
+	code, fields := classifyError(err)
 	response := models.Response{
 		Success: false,
-		Message: message,
+		Message: err.Error(),
+		Code:    code,
+		Fields:  fields,
 	}
-	
+
 	jsonData, _ := json.Marshal(response)
 	return string(jsonData)
 }
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// decodeResponse unmarshals an API response, failing the test on bad JSON
+func decodeResponse(t *testing.T, raw string) models.Response {
+	t.Helper()
+
+	var resp models.Response
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", raw, err)
+	}
+	return resp
+}
+
+// TestRestoreWhileReading restores the database repeatedly while readers
+// query it. Run with -race to check the swap is synchronized.
+func TestRestoreWhileReading(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "tracker.db")
+	backupPath := filepath.Join(dir, "tracker.bak")
+
+	if resp := decodeResponse(t, InitDB(dbPath)); !resp.Success {
+		t.Fatalf("InitDB: %s", resp.Message)
+	}
+	t.Cleanup(func() {
+		stateMu.Lock()
+		closeActive()
+		stateMu.Unlock()
+	})
+
+	for i := 0; i < 20; i++ {
+		problem := fmt.Sprintf(`{"name":"Problem %d","platform":"LeetCode","difficulty":"Easy","tags":[{"name":"dp"}]}`, i)
+		if resp := decodeResponse(t, AddProblem(problem)); !resp.Success {
+			t.Fatalf("AddProblem: %s", resp.Message)
+		}
+	}
+	if resp := decodeResponse(t, BackupDatabase(dbPath, backupPath)); !resp.Success {
+		t.Fatalf("BackupDatabase: %s", resp.Message)
+	}
+
+	const readers = 8
+	stop := make(chan struct{})
+	failures := make(chan string, readers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, raw := range []string{GetProblems(`{"tags":["dp"]}`), GetTags(), GetStatistics()} {
+					var resp models.Response
+					if err := json.Unmarshal([]byte(raw), &resp); err != nil || !resp.Success {
+						failures <- raw
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	restores := 0
+	for time.Now().Before(deadline) {
+		if resp := decodeResponse(t, RestoreDatabase(dbPath, backupPath)); !resp.Success {
+			t.Errorf("RestoreDatabase: %s", resp.Message)
+			break
+		}
+		restores++
+	}
+	close(stop)
+	wg.Wait()
+	close(failures)
+
+	for raw := range failures {
+		t.Errorf("reader failed during restore: %s", raw)
+	}
+	if restores == 0 {
+		t.Fatal("no restores completed")
+	}
+
+	resp := decodeResponse(t, GetProblems("{}"))
+	problems, _ := resp.Data.([]interface{})
+	if len(problems) != 20 {
+		t.Errorf("got %d problems after restores, want 20", len(problems))
+	}
+}
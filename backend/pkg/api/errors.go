@@ -0,0 +1,50 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+// classifyError maps an error to a stable error code and any field details
+func classifyError(err error) (models.ErrorCode, []models.FieldError) {
+	var appErr *models.Error
+	if errors.As(err, &appErr) {
+		return appErr.Code, appErr.Fields
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrCodeNotFound, nil
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return models.ErrCodeConflict, nil
+		case sqlite3.ErrConstraintForeignKey, sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+			return models.ErrCodeValidation, nil
+		}
+		switch sqliteErr.Code {
+		case sqlite3.ErrIoErr, sqlite3.ErrCantOpen, sqlite3.ErrFull, sqlite3.ErrReadonly,
+			sqlite3.ErrNotADB, sqlite3.ErrCorrupt:
+			return models.ErrCodeIO, nil
+		}
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return models.ErrCodeIO, nil
+	}
+
+	return models.ErrCodeInternal, nil
+}
+
+// invalidInput creates a VALIDATION error for malformed request input
+func invalidInput(format string, args ...interface{}) error {
+	return models.NewError(models.ErrCodeValidation, format, args...)
+}
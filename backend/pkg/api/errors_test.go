@@ -0,0 +1,60 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/algorithmtracker/backend/internal/models"
+)
+
+func TestClassifyError(t *testing.T) {
+	fields := []models.FieldError{{Field: "name", Message: "problem name is required"}}
+	constraint := func(extended sqlite3.ErrNoExtended) error {
+		return sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: extended}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   models.ErrorCode
+		wantFields []models.FieldError
+	}{
+		{"application error", models.NewValidationError(fields), models.ErrCodeValidation, fields},
+		{"wrapped application error", fmt.Errorf("saving: %w", models.NewError(models.ErrCodeNotFound, "problem 3 not found")),
+			models.ErrCodeNotFound, nil},
+		{"application code wins over its cause", models.WrapError(models.ErrCodeIO, constraint(sqlite3.ErrConstraintUnique), "disk"),
+			models.ErrCodeIO, nil},
+		{"no rows", fmt.Errorf("lookup: %w", sql.ErrNoRows), models.ErrCodeNotFound, nil},
+		{"unique constraint", constraint(sqlite3.ErrConstraintUnique), models.ErrCodeConflict, nil},
+		{"primary key", constraint(sqlite3.ErrConstraintPrimaryKey), models.ErrCodeConflict, nil},
+		{"foreign key", constraint(sqlite3.ErrConstraintForeignKey), models.ErrCodeValidation, nil},
+		{"not null", constraint(sqlite3.ErrConstraintNotNull), models.ErrCodeValidation, nil},
+		{"check", constraint(sqlite3.ErrConstraintCheck), models.ErrCodeValidation, nil},
+		{"disk full", sqlite3.Error{Code: sqlite3.ErrFull}, models.ErrCodeIO, nil},
+		{"cannot open", fmt.Errorf("open: %w", sqlite3.Error{Code: sqlite3.ErrCantOpen}), models.ErrCodeIO, nil},
+		{"not a database", sqlite3.Error{Code: sqlite3.ErrNotADB}, models.ErrCodeIO, nil},
+		{"busy", sqlite3.Error{Code: sqlite3.ErrBusy}, models.ErrCodeInternal, nil},
+		{"path error", &fs.PathError{Op: "open", Path: "/missing", Err: fs.ErrNotExist}, models.ErrCodeIO, nil},
+		{"anything else", errors.New("boom"), models.ErrCodeInternal, nil},
+	}
+
+	for _, tt := range tests {
+		code, fields := classifyError(tt.err)
+		if code != tt.wantCode || !reflect.DeepEqual(fields, tt.wantFields) {
+			t.Errorf("%s: got %s %v, want %s %v", tt.name, code, fields, tt.wantCode, tt.wantFields)
+		}
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	resp := decodeResponse(t, errorResponse(models.NewError(models.ErrCodeNotFound, "problem 42 not found")))
+	if resp.Success || resp.Code != models.ErrCodeNotFound || resp.Message != "problem 42 not found" {
+		t.Errorf("response = %+v", resp)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/database"
+	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/service"
+)
+
+// crashLogName is the file panics are logged to, next to the database once
+// InitDB has been called and in the temp directory before that
+const crashLogName = "algorithm_tracker_crash.log"
+
+// stateMu guards the active service, which is nil until InitDB succeeds. API
+// calls hold the read lock for their whole duration, so InitDB and
+// RestoreDatabase take the write lock to wait for in-flight calls before
+// swapping the database.
+var (
+	stateMu sync.RWMutex
+	active  *service.Service
+)
+
+var (
+	logMu        sync.Mutex
+	crashLogPath = filepath.Join(os.TempDir(), crashLogName)
+)
+
+var errNotInitialized = models.NewError(models.ErrCodeNotInitialized,
+	"database is not initialized, call InitDB first")
+
+// initialize opens the database at dbPath and builds the service, replacing
+// any previously opened database. If the new database cannot be opened the
+// previous one stays active.
+func initialize(dbPath string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return err
+	}
+
+	setCrashLogDir(filepath.Dir(dbPath))
+	closeActive()
+	active = service.NewService(db)
+	startBackupSchedule(active)
+	return nil
+}
+
+// restore replaces the database at dbPath with the backup and swaps in the
+// service built on the restored file. On failure the service stays on the
+// previous data, which the restore rolls back to if needed.
+func restore(dbPath, backupPath string) (*models.RestoreResult, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if active == nil {
+		return nil, errNotInitialized
+	}
+
+	previous := active
+	next, result, err := active.RestoreDatabase(dbPath, backupPath)
+	if next != previous {
+		stopBackupSchedule()
+		active = next
+		if next != nil {
+			startBackupSchedule(next)
+		}
+	}
+	return result, err
+}
+
+// closeActive closes the active service. The caller must hold the write lock.
+func closeActive() {
+	stopBackupSchedule()
+	if active != nil {
+		active.Close()
+		active = nil
+	}
+}
+
+// withService runs fn with the initialized service while holding the read
+// lock. Calls made before InitDB get a NOT_INITIALIZED response and panics
+// are turned into INTERNAL errors instead of crashing the host process.
+func withService(op string, fn func(svc *service.Service) string) (result string) {
+	defer recoverPanic(op, &result)
+
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+
+	if active == nil {
+		return errorResponse(errNotInitialized)
+	}
+	return fn(active)
+}
+
+// setCrashLogDir moves the crash log into dir
+func setCrashLogDir(dir string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	crashLogPath = filepath.Join(dir, crashLogName)
+}
+
+// recoverPanic converts a panic in op into an error response stored in result
+// and logs the stack trace. It must be deferred directly.
+func recoverPanic(op string, result *string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	logPanic(op, r, debug.Stack())
+	*result = errorResponse(models.NewError(models.ErrCodeInternal, "internal error in %s: %v", op, r))
+}
+
+// logPanic appends a panic report to the crash log
+func logPanic(op string, value interface{}, stack []byte) {
+	appendCrashLog("panic in %s: %v\n%s", op, value, stack)
+}
+
+// logError records a failure of background work, which has no caller to
+// report it to, in the crash log
+func logError(op string, err error) {
+	appendCrashLog("error in %s: %v", op, err)
+}
+
+// appendCrashLog appends a timestamped entry to the crash log, ignoring
+// logging failures
+func appendCrashLog(format string, args ...interface{}) {
+	logMu.Lock()
+	path := crashLogPath
+	logMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/algorithmtracker/backend/internal/models"
+	"github.com/algorithmtracker/backend/internal/service"
+)
+
+// resetState closes any open database and points the crash log at a fresh
+// directory, undoing both when the test ends
+func resetState(t *testing.T) string {
+	t.Helper()
+
+	stateMu.Lock()
+	closeActive()
+	stateMu.Unlock()
+
+	logMu.Lock()
+	previousLog := crashLogPath
+	logMu.Unlock()
+	dir := t.TempDir()
+	setCrashLogDir(dir)
+
+	t.Cleanup(func() {
+		stateMu.Lock()
+		closeActive()
+		stateMu.Unlock()
+		logMu.Lock()
+		crashLogPath = previousLog
+		logMu.Unlock()
+	})
+	return dir
+}
+
+func TestCallsBeforeInitDB(t *testing.T) {
+	resetState(t)
+
+	called := false
+	resp := decodeResponse(t, withService("Probe", func(svc *service.Service) string {
+		called = true
+		return successResponse("ok", nil)
+	}))
+	if called {
+		t.Error("operation ran without a database")
+	}
+	if resp.Success || resp.Code != models.ErrCodeNotInitialized {
+		t.Errorf("response = %+v, want NOT_INITIALIZED", resp)
+	}
+
+	for name, raw := range map[string]string{
+		"GetProblem":  GetProblem(1),
+		"GetProblems": GetProblems(""),
+		"DeleteTag":   DeleteTag(1),
+	} {
+		if resp := decodeResponse(t, raw); resp.Code != models.ErrCodeNotInitialized {
+			t.Errorf("%s before InitDB = %+v, want NOT_INITIALIZED", name, resp)
+		}
+	}
+}
+
+func TestPanicBecomesInternalError(t *testing.T) {
+	dir := resetState(t)
+	if resp := decodeResponse(t, InitDB(filepath.Join(dir, "tracker.db"))); !resp.Success {
+		t.Fatalf("InitDB: %s", resp.Message)
+	}
+
+	resp := decodeResponse(t, withService("Explode", func(svc *service.Service) string {
+		var problems []models.Problem
+		return problems[3].Name
+	}))
+	if resp.Success || resp.Code != models.ErrCodeInternal || !strings.Contains(resp.Message, "internal error in Explode") {
+		t.Errorf("response = %+v, want INTERNAL from Explode", resp)
+	}
+
+	log, err := os.ReadFile(filepath.Join(dir, crashLogName))
+	if err != nil {
+		t.Fatalf("crash log: %v", err)
+	}
+	if !strings.Contains(string(log), "panic in Explode: runtime error: index out of range") ||
+		!strings.Contains(string(log), "guard_test.go") {
+		t.Errorf("crash log lacks the panic or its stack:\n%s", log)
+	}
+
+	// The read lock was released, so the database can still be swapped and
+	// used afterwards
+	if resp := decodeResponse(t, InitDB(filepath.Join(dir, "tracker.db"))); !resp.Success {
+		t.Fatalf("InitDB after a panic: %s", resp.Message)
+	}
+	if resp := decodeResponse(t, GetProblems("")); !resp.Success {
+		t.Errorf("GetProblems after a panic: %s", resp.Message)
+	}
+}
+
+func TestRecoverPanicWithoutPanic(t *testing.T) {
+	result := func() (result string) {
+		defer recoverPanic("Calm", &result)
+		return "unchanged"
+	}()
+	if result != "unchanged" {
+		t.Errorf("result = %q", result)
+	}
+}
+
+func TestFailedInitDBKeepsPreviousDatabase(t *testing.T) {
+	dir := resetState(t)
+	if resp := decodeResponse(t, InitDB(filepath.Join(dir, "tracker.db"))); !resp.Success {
+		t.Fatalf("InitDB: %s", resp.Message)
+	}
+	if resp := decodeResponse(t, AddProblem(`{"name":"Two Sum","platform":"LeetCode","difficulty":"Easy"}`)); !resp.Success {
+		t.Fatalf("AddProblem: %s", resp.Message)
+	}
+
+	if resp := decodeResponse(t, InitDB(filepath.Join(dir, "missing", "tracker.db"))); resp.Success {
+		t.Fatal("InitDB in a missing directory succeeded")
+	}
+
+	resp := decodeResponse(t, GetProblems(""))
+	if !resp.Success {
+		t.Fatalf("GetProblems after a failed InitDB: %+v", resp)
+	}
+	if problems, ok := resp.Data.([]interface{}); !ok || len(problems) != 1 {
+		t.Errorf("problems after a failed InitDB = %v, want the one added before", resp.Data)
+	}
+}
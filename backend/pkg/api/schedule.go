@@ -0,0 +1,68 @@
+package api
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/algorithmtracker/backend/internal/service"
+)
+
+// backupCheckInterval is how often a loaded library checks whether a
+// scheduled backup is due; the backup policy decides whether one is taken
+const backupCheckInterval = time.Hour
+
+// stopBackups stops the schedule of the active service. It is guarded by the
+// write lock of stateMu like active itself.
+var stopBackups chan struct{}
+
+// startBackupSchedule checks for a due backup of svc right away and then every
+// backupCheckInterval until stopBackupSchedule is called. The caller must hold
+// the write lock.
+func startBackupSchedule(svc *service.Service) {
+	stop := make(chan struct{})
+	stopBackups = stop
+
+	go func() {
+		ticker := time.NewTicker(backupCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			runScheduledBackup(svc)
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopBackupSchedule stops the running schedule, if any. The caller must hold
+// the write lock.
+func stopBackupSchedule() {
+	if stopBackups != nil {
+		close(stopBackups)
+		stopBackups = nil
+	}
+}
+
+// runScheduledBackup takes a due backup of svc unless it has been replaced
+// since the schedule started. Failures are logged since nobody is waiting on
+// the result.
+func runScheduledBackup(svc *service.Service) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic("ScheduledBackup", r, debug.Stack())
+		}
+	}()
+
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+
+	if active != svc {
+		return
+	}
+	if _, err := svc.RunScheduledBackup(); err != nil {
+		logError("ScheduledBackup", err)
+	}
+}